@@ -0,0 +1,246 @@
+// Package audit keeps an append-only log of rating changes to explain how
+// a rating got to its value.
+package audit
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gami/go-trueskill"
+)
+
+// Event is a change of the rating of a player.
+type Event struct {
+	Seq       uint64             `json:"seq"`
+	PlayerID  string             `json:"player_id"`
+	MatchID   string             `json:"match_id"`
+	Params    map[string]float64 `json:"params"`          // parameters of the environment which rated the match.
+	Prior     *trueskill.Rating  `json:"prior,omitempty"` // nil for a new player.
+	Posterior *trueskill.Rating  `json:"posterior"`
+	Time      time.Time          `json:"time"`
+}
+
+// clone copies the event with its params and ratings, so that the log shares
+// nothing with callers.
+func (e *Event) clone() *Event {
+	copied := *e
+	if e.Params != nil {
+		copied.Params = make(map[string]float64, len(e.Params))
+		for k, v := range e.Params {
+			copied.Params[k] = v
+		}
+	}
+	if e.Prior != nil {
+		prior := *e.Prior
+		copied.Prior = &prior
+	}
+	if e.Posterior != nil {
+		posterior := *e.Posterior
+		copied.Posterior = &posterior
+	}
+	return &copied
+}
+
+// Log is an append-only log of events. Events may also be written to a
+// writer as JSON lines, which Load reads back.
+type Log struct {
+	mu       sync.RWMutex
+	events   []*Event
+	byPlayer map[string][]int // indexes of events by player ID.
+	w        io.Writer
+	failed   error // set when a write fails, which may leave a torn line.
+}
+
+type option func(*Log)
+
+func New(options ...option) *Log {
+	l := &Log{
+		byPlayer: make(map[string][]int),
+	}
+
+	for _, opt := range options {
+		opt(l)
+	}
+
+	return l
+}
+
+// WithWriter writes each appended event to w as a JSON line. After a write
+// fails, the log refuses further appends, so that a torn line can only be
+// the last one.
+func WithWriter(w io.Writer) option {
+	return func(l *Log) {
+		l.w = w
+	}
+}
+
+// Load reads events written by WithWriter and returns a log of them. A torn
+// line at the end, left by a failed write, is discarded.
+func Load(r io.Reader, options ...option) (*Log, error) {
+	l := New(options...)
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		torn := err == io.EOF
+		if len(line) > 0 {
+			e := &Event{}
+			if jsonErr := json.Unmarshal(line, e); jsonErr != nil {
+				if torn {
+					break
+				}
+				return nil, jsonErr
+			}
+			l.add(e)
+		}
+		if torn {
+			break
+		}
+	}
+
+	return l, nil
+}
+
+// Record appends an event for each player in after. before has the ratings
+// before the match, and misses new players. The events are written in a
+// single write, and none of them is appended if it fails. A writer which
+// fails partway may still keep the lines before the torn one.
+func (l *Log) Record(matchID string, params map[string]float64, before map[string]*trueskill.Rating, after map[string]*trueskill.Rating, at time.Time) error {
+	ids := make([]string, 0, len(after))
+	for id := range after {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	events := make([]*Event, 0, len(ids))
+	for _, id := range ids {
+		events = append(events, &Event{
+			PlayerID:  id,
+			MatchID:   matchID,
+			Params:    params,
+			Prior:     before[id],
+			Posterior: after[id],
+			Time:      at,
+		})
+	}
+
+	return l.append(events)
+}
+
+// Append adds the event with the next sequence number.
+func (l *Log) Append(e *Event) error {
+	return l.append([]*Event{e})
+}
+
+// append adds copies of the events with the next sequence numbers after
+// writing them all.
+func (l *Log) append(events []*Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.failed != nil {
+		return l.failed
+	}
+
+	copied := make([]*Event, 0, len(events))
+	var data []byte
+	for i, e := range events {
+		c := e.clone()
+		c.Seq = uint64(len(l.events)+i) + 1
+		copied = append(copied, c)
+
+		if l.w != nil {
+			line, err := json.Marshal(c)
+			if err != nil {
+				return err
+			}
+			data = append(append(data, line...), '\n')
+		}
+	}
+
+	if l.w != nil {
+		if _, err := l.w.Write(data); err != nil {
+			l.failed = fmt.Errorf("audit log is not writable after a failed write: %w", err)
+			return err
+		}
+	}
+
+	for _, c := range copied {
+		l.add(c)
+	}
+	return nil
+}
+
+func (l *Log) add(e *Event) {
+	l.byPlayer[e.PlayerID] = append(l.byPlayer[e.PlayerID], len(l.events))
+	l.events = append(l.events, e)
+}
+
+// Query returns the events of the player in [from, to) in order. A zero
+// time leaves the range open on that side.
+func (l *Log) Query(playerID string, from time.Time, to time.Time) []*Event {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	events := make([]*Event, 0)
+	for _, i := range l.byPlayer[playerID] {
+		e := l.events[i]
+		if !from.IsZero() && e.Time.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !e.Time.Before(to) {
+			continue
+		}
+		events = append(events, e.clone())
+	}
+	return events
+}
+
+// Trajectory returns every event of the player in order.
+func (l *Log) Trajectory(playerID string) []*Event {
+	return l.Query(playerID, time.Time{}, time.Time{})
+}
+
+// WriteTrajectoryCSV exports the trajectory of the player as CSV with a
+// header row.
+func (l *Log) WriteTrajectoryCSV(w io.Writer, playerID string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"seq", "time", "match_id", "prior_mu", "prior_sigma", "mu", "sigma"}); err != nil {
+		return err
+	}
+
+	format := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	for _, e := range l.Trajectory(playerID) {
+		priorMu, priorSigma := "", ""
+		if e.Prior != nil {
+			priorMu, priorSigma = format(e.Prior.Mu), format(e.Prior.Sigma)
+		}
+		err := cw.Write([]string{
+			strconv.FormatUint(e.Seq, 10),
+			e.Time.Format(time.RFC3339Nano),
+			e.MatchID,
+			priorMu,
+			priorSigma,
+			format(e.Posterior.Mu),
+			format(e.Posterior.Sigma),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,146 @@
+package audit_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/audit"
+)
+
+func TestLog(t *testing.T) {
+	env := trueskill.NewTrueSkill()
+	buf := &bytes.Buffer{}
+	l := audit.New(audit.WithWriter(buf))
+
+	ratings := make(map[string]*trueskill.Rating)
+	matches := []*trueskill.Match{
+		{ID: "m1", Time: time.Unix(100, 0).UTC(), Teams: [][]string{{"a"}, {"b"}}},
+		{ID: "m2", Time: time.Unix(200, 0).UTC(), Teams: [][]string{{"b"}, {"c"}}},
+		{ID: "m3", Time: time.Unix(300, 0).UTC(), Teams: [][]string{{"a"}, {"b"}}},
+	}
+	for _, m := range matches {
+		before := make(map[string]*trueskill.Rating)
+		for _, id := range m.Players() {
+			if r, ok := ratings[id]; ok {
+				before[id] = r
+			}
+		}
+		after, err := env.RateMatch(m, ratings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Record(m.ID, env.Params(), before, after, m.Time); err != nil {
+			t.Fatal(err)
+		}
+		for id, r := range after {
+			ratings[id] = r
+		}
+	}
+
+	b := l.Trajectory("b")
+	if len(b) != 3 || b[0].Prior != nil || b[2].Prior.Mu != b[1].Posterior.Mu || b[2].Posterior.Mu != ratings["b"].Mu {
+		t.Errorf("got %+v", b)
+	}
+	if b[0].Params["beta"] != env.Beta() {
+		t.Errorf("got params %v", b[0].Params)
+	}
+
+	ranged := l.Query("b", time.Unix(200, 0), time.Unix(300, 0))
+	if len(ranged) != 1 || ranged[0].MatchID != "m2" {
+		t.Errorf("got %+v", ranged)
+	}
+
+	loaded, err := audit.Load(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Trajectory("b"); !reflect.DeepEqual(got, b) {
+		t.Errorf("got %+v, want %+v", got, b)
+	}
+
+	out := &bytes.Buffer{}
+	if err := l.WriteTrajectoryCSV(out, "a"); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "1,") || !strings.Contains(lines[1], ",m1,,,") {
+		t.Errorf("got %q", out.String())
+	}
+}
+
+func TestLogCopies(t *testing.T) {
+	l := audit.New()
+	params := map[string]float64{"beta": 4}
+	posterior := trueskill.NewRating(30, 7, 1)
+	if err := l.Append(&audit.Event{PlayerID: "a", MatchID: "m1", Params: params, Posterior: posterior}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changes by the caller after Append do not reach the log.
+	params["beta"] = 0
+	posterior.Mu = 0
+
+	got := l.Trajectory("a")
+	if got[0].Params["beta"] != 4 || got[0].Posterior.Mu != 30 {
+		t.Fatalf("got %+v", got[0])
+	}
+
+	// Nor do changes to the events returned.
+	got[0].Params["beta"] = 0
+	got[0].Posterior.Mu = 0
+	if again := l.Trajectory("a"); again[0].Params["beta"] != 4 || again[0].Posterior.Mu != 30 {
+		t.Errorf("got %+v", again[0])
+	}
+}
+
+// shortWriter writes up to n bytes and then fails.
+type shortWriter struct {
+	buf bytes.Buffer
+	n   int
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	if len(p) <= w.n {
+		w.n -= len(p)
+		return w.buf.Write(p)
+	}
+	written, _ := w.buf.Write(p[:w.n])
+	w.n = 0
+	return written, errors.New("disk full")
+}
+
+func TestLogTornWrite(t *testing.T) {
+	w := &shortWriter{n: 1 << 20}
+	l := audit.New(audit.WithWriter(w))
+	rating := trueskill.NewRating(25, 8, 1)
+
+	if err := l.Append(&audit.Event{PlayerID: "a", MatchID: "m1", Posterior: rating}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Neither player of the match is logged when the write fails.
+	w.n = 50
+	after := map[string]*trueskill.Rating{"a": rating, "b": rating}
+	if err := l.Record("m2", nil, nil, after, time.Unix(0, 0)); err == nil {
+		t.Fatal("failed write must be an error")
+	}
+	if len(l.Trajectory("a")) != 1 || len(l.Trajectory("b")) != 0 {
+		t.Errorf("got %+v %+v", l.Trajectory("a"), l.Trajectory("b"))
+	}
+	if err := l.Append(&audit.Event{PlayerID: "a", MatchID: "m3", Posterior: rating}); err == nil {
+		t.Error("log must refuse appends after a failed write")
+	}
+
+	loaded, err := audit.Load(bytes.NewReader(w.buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.Trajectory("a"); len(got) != 1 || got[0].MatchID != "m1" {
+		t.Errorf("got %+v", got)
+	}
+}
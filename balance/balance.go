@@ -0,0 +1,344 @@
+// Package balance splits a pool of players into teams of the best match quality.
+package balance
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/gami/go-trueskill"
+)
+
+// ErrNoFit is returned when the parties cannot fill the teams exactly.
+var ErrNoFit = errors.New("parties cannot fit in the teams")
+
+// Player is a player in the pool.
+type Player struct {
+	ID     string
+	Rating *trueskill.Rating
+}
+
+// Result is teams of player IDs and the match quality.
+type Result struct {
+	Teams   [][]string
+	Quality float64
+	Exact   bool // the result is the best of all partitions.
+}
+
+// Balancer partitions players into teams.
+type Balancer struct {
+	env        trueskill.Rater
+	parties    [][]string
+	exactLimit int
+	maxRounds  int
+}
+
+type option func(*Balancer)
+
+func New(env trueskill.Rater, options ...option) *Balancer {
+	b := &Balancer{
+		env:        env,
+		exactLimit: 12,
+		maxRounds:  100,
+	}
+
+	for _, opt := range options {
+		opt(b)
+	}
+
+	return b
+}
+
+// Parties keeps the players of each party in the same team.
+func Parties(parties ...[]string) option {
+	return func(b *Balancer) {
+		b.parties = parties
+	}
+}
+
+// ExactLimit is the maximum number of solos and parties searched exactly.
+// Larger pools are balanced by local search. The default is 12.
+func ExactLimit(n int) option {
+	return func(b *Balancer) {
+		b.exactLimit = n
+	}
+}
+
+// MaxRounds limits the rounds of the local search. The default is 100.
+func MaxRounds(n int) option {
+	return func(b *Balancer) {
+		b.maxRounds = n
+	}
+}
+
+// unit is players who are assigned together.
+type unit []*Player
+
+// Balance partitions the pool into teams of the given sizes. The sizes must
+// add up to the size of the pool.
+func (b *Balancer) Balance(pool []*Player, sizes []int) (*Result, error) {
+	units, err := b.units(pool, sizes)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &search{
+		env:    b.env,
+		units:  units,
+		sizes:  sizes,
+		assign: make([]int, len(units)),
+		filled: make([]int, len(sizes)),
+	}
+
+	if len(units) <= b.exactLimit {
+		if err := s.exact(0); err != nil {
+			return nil, err
+		}
+		if s.best == nil {
+			return nil, ErrNoFit
+		}
+		return s.result(true), nil
+	}
+
+	if err := s.local(b.maxRounds); err != nil {
+		return nil, err
+	}
+	return s.result(false), nil
+}
+
+func (b *Balancer) units(pool []*Player, sizes []int) ([]unit, error) {
+	if len(sizes) < 2 {
+		return nil, errors.New("need multiple teams")
+	}
+
+	total := 0
+	for _, size := range sizes {
+		if size < 1 {
+			return nil, errors.New("each team must have players")
+		}
+		total += size
+	}
+	if total != len(pool) {
+		return nil, fmt.Errorf("teams need %d players, but the pool has %d", total, len(pool))
+	}
+
+	byID := make(map[string]*Player, len(pool))
+	for _, p := range pool {
+		if _, ok := byID[p.ID]; ok {
+			return nil, fmt.Errorf("player %s appears twice", p.ID)
+		}
+		byID[p.ID] = p
+	}
+
+	units := make([]unit, 0, len(pool))
+	inParty := make(map[string]bool)
+	for _, party := range b.parties {
+		u := make(unit, 0, len(party))
+		for _, id := range party {
+			p, ok := byID[id]
+			if !ok {
+				return nil, fmt.Errorf("party member %s is not in the pool", id)
+			}
+			if inParty[id] {
+				return nil, fmt.Errorf("player %s is in multiple parties", id)
+			}
+			inParty[id] = true
+			u = append(u, p)
+		}
+		if len(u) > 0 {
+			units = append(units, u)
+		}
+	}
+	for _, p := range pool {
+		if !inParty[p.ID] {
+			units = append(units, unit{p})
+		}
+	}
+
+	// Larger units first fail earlier in the exact search.
+	sort.SliceStable(units, func(i, j int) bool {
+		return len(units[i]) > len(units[j])
+	})
+
+	return units, nil
+}
+
+// search is the state of a partition search.
+type search struct {
+	env    trueskill.Rater
+	units  []unit
+	sizes  []int
+	assign []int // the team of each unit.
+	filled []int // the number of players in each team.
+
+	best        []int
+	bestQuality float64
+}
+
+func (s *search) quality() (float64, error) {
+	groups := make([][]*trueskill.Rating, len(s.sizes))
+	for i, u := range s.units {
+		for _, p := range u {
+			groups[s.assign[i]] = append(groups[s.assign[i]], p.Rating)
+		}
+	}
+	return s.env.Quality(groups)
+}
+
+func (s *search) record() error {
+	q, err := s.quality()
+	if err != nil {
+		return err
+	}
+	if s.best == nil || q > s.bestQuality {
+		s.best = append([]int(nil), s.assign...)
+		s.bestQuality = q
+	}
+	return nil
+}
+
+// exact tries every partition. An empty team is skipped if an earlier empty
+// team has the same size, since swapping them makes the same match.
+func (s *search) exact(i int) error {
+	if i == len(s.units) {
+		return s.record()
+	}
+
+	size := len(s.units[i])
+	for t := range s.sizes {
+		if s.filled[t]+size > s.sizes[t] {
+			continue
+		}
+		if s.filled[t] == 0 && s.hasEarlierEmpty(t) {
+			continue
+		}
+
+		s.assign[i] = t
+		s.filled[t] += size
+		err := s.exact(i + 1)
+		s.filled[t] -= size
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *search) hasEarlierEmpty(t int) bool {
+	for e := 0; e < t; e++ {
+		if s.filled[e] == 0 && s.sizes[e] == s.sizes[t] {
+			return true
+		}
+	}
+	return false
+}
+
+// local starts from a greedy partition and swaps units of the same size
+// between teams while the quality improves.
+func (s *search) local(maxRounds int) error {
+	if err := s.greedy(); err != nil {
+		return err
+	}
+	if err := s.record(); err != nil {
+		return err
+	}
+
+	for round := 0; round < maxRounds; round++ {
+		improved := false
+
+		for i := range s.units {
+			for j := i + 1; j < len(s.units); j++ {
+				ti, tj := s.assign[i], s.assign[j]
+				if ti == tj || len(s.units[i]) != len(s.units[j]) {
+					continue
+				}
+
+				s.assign[i], s.assign[j] = tj, ti
+				q, err := s.quality()
+				if err != nil {
+					return err
+				}
+				if q > s.bestQuality {
+					s.best = append(s.best[:0], s.assign...)
+					s.bestQuality = q
+					improved = true
+				} else {
+					s.assign[i], s.assign[j] = ti, tj
+				}
+			}
+		}
+
+		if !improved {
+			break
+		}
+	}
+
+	return nil
+}
+
+// greedy deals units from the strongest to the team with the lowest total
+// mean among teams with room.
+func (s *search) greedy() error {
+	order := make([]int, len(s.units))
+	for i := range order {
+		order[i] = i
+	}
+	mean := func(u unit) float64 {
+		sum := 0.0
+		for _, p := range u {
+			sum += p.Rating.Mu
+		}
+		return sum / float64(len(u))
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ua, ub := s.units[order[a]], s.units[order[b]]
+		if len(ua) != len(ub) {
+			return len(ua) > len(ub)
+		}
+		return mean(ua) > mean(ub)
+	})
+
+	totals := make([]float64, len(s.sizes))
+	for _, i := range order {
+		u := s.units[i]
+		team := -1
+		for t := range s.sizes {
+			if s.filled[t]+len(u) > s.sizes[t] {
+				continue
+			}
+			if team < 0 || totals[t] < totals[team] {
+				team = t
+			}
+		}
+		if team < 0 {
+			return ErrNoFit
+		}
+
+		s.assign[i] = team
+		s.filled[team] += len(u)
+		for _, p := range u {
+			totals[team] += p.Rating.Mu
+		}
+	}
+
+	return nil
+}
+
+func (s *search) result(exact bool) *Result {
+	teams := make([][]string, len(s.sizes))
+	for i, u := range s.units {
+		for _, p := range u {
+			teams[s.best[i]] = append(teams[s.best[i]], p.ID)
+		}
+	}
+	for _, team := range teams {
+		sort.Strings(team)
+	}
+
+	return &Result{
+		Teams:   teams,
+		Quality: s.bestQuality,
+		Exact:   exact,
+	}
+}
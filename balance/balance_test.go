@@ -0,0 +1,85 @@
+package balance_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/balance"
+)
+
+func pool(n int) []*balance.Player {
+	players := make([]*balance.Player, 0, n)
+	for i := 0; i < n; i++ {
+		players = append(players, &balance.Player{
+			ID:     fmt.Sprintf("p%02d", i),
+			Rating: trueskill.NewRating(15+float64(i*2), 3, 1),
+		})
+	}
+	return players
+}
+
+func teamOf(teams [][]string, id string) int {
+	for i, team := range teams {
+		for _, member := range team {
+			if member == id {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func TestBalance(t *testing.T) {
+	ts := trueskill.NewTrueSkill()
+
+	exact, err := balance.New(ts).Balance(pool(10), []int{5, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exact.Exact || len(exact.Teams[0]) != 5 || len(exact.Teams[1]) != 5 {
+		t.Fatalf("got %+v", exact)
+	}
+
+	// The strongest and the weakest players are in a team against the other.
+	if teamOf(exact.Teams, "p00") != teamOf(exact.Teams, "p09") {
+		t.Errorf("got %+v", exact.Teams)
+	}
+
+	local, err := balance.New(ts, balance.ExactLimit(0)).Balance(pool(10), []int{5, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if local.Exact || local.Quality > exact.Quality+1e-12 || local.Quality < exact.Quality*0.95 {
+		t.Errorf("local search quality %v must be close to exact %v", local.Quality, exact.Quality)
+	}
+}
+
+func TestBalanceParties(t *testing.T) {
+	ts := trueskill.NewTrueSkill()
+
+	for _, limit := range []int{12, 0} {
+		r, err := balance.New(ts,
+			balance.ExactLimit(limit),
+			balance.Parties([]string{"p08", "p09"}, []string{"p00", "p01", "p02"}),
+		).Balance(pool(10), []int{5, 5})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if teamOf(r.Teams, "p08") != teamOf(r.Teams, "p09") {
+			t.Errorf("limit=%d party p08,p09 is split: %+v", limit, r.Teams)
+		}
+		if teamOf(r.Teams, "p00") != teamOf(r.Teams, "p01") || teamOf(r.Teams, "p00") != teamOf(r.Teams, "p02") {
+			t.Errorf("limit=%d party p00,p01,p02 is split: %+v", limit, r.Teams)
+		}
+	}
+
+	if _, err := balance.New(ts).Balance(pool(9), []int{5, 5}); err == nil {
+		t.Error("pool smaller than the teams must be an error")
+	}
+	if _, err := balance.New(ts, balance.Parties([]string{"p00", "p01", "p02"})).Balance(pool(4), []int{2, 2}); !errors.Is(err, balance.ErrNoFit) {
+		t.Errorf("party larger than a team: got %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+// Package elo implements the Elo rating system as a trueskill.Rater.
+package elo
+
+import (
+	"errors"
+	"math"
+
+	"github.com/gami/go-trueskill"
+)
+
+// Elo represents environment of the Elo rating. Ratings only use Mu.
+type Elo struct {
+	initial float64 // the rating of a new player.
+	k       float64 // the largest change of a rating by a match.
+	scale   float64 // the rating difference at which the better player is 10 times as likely to win.
+}
+
+type option func(*Elo)
+
+func New(options ...option) *Elo {
+	e := &Elo{
+		initial: 1500,
+		k:       32,
+		scale:   400,
+	}
+
+	for _, opt := range options {
+		opt(e)
+	}
+
+	return e
+}
+
+// Initial is the rating of a new player. The default is 1500.
+func Initial(v float64) option {
+	return func(e *Elo) {
+		e.initial = v
+	}
+}
+
+// K is the largest change of a rating by a match. The default is 32.
+func K(v float64) option {
+	return func(e *Elo) {
+		e.k = v
+	}
+}
+
+// Scale is the rating difference at which the better player is 10 times as
+// likely to win. The default is 400.
+func Scale(v float64) option {
+	return func(e *Elo) {
+		e.scale = v
+	}
+}
+
+var _ trueskill.Rater = (*Elo)(nil)
+
+func (e *Elo) CreateRating() *trueskill.Rating {
+	return trueskill.NewRating(e.initial, 0, 1)
+}
+
+// team returns the mean rating of the team.
+func team(ratings []*trueskill.Rating) float64 {
+	sum := 0.0
+	for _, r := range ratings {
+		sum += r.Mu
+	}
+	return sum / float64(len(ratings))
+}
+
+func (e *Elo) expected(a float64, b float64) float64 {
+	return 1 / (1 + math.Pow(10, (b-a)/e.scale))
+}
+
+// RateMatch compares every pair of teams by their mean ratings. Each player
+// gets the change of the team, divided by the number of opponent teams.
+func (e *Elo) RateMatch(m *trueskill.Match, ratings map[string]*trueskill.Rating) (map[string]*trueskill.Rating, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	groups := trueskill.Groups(e, m, ratings)
+	means := make([]float64, 0, len(groups))
+	for _, g := range groups {
+		means = append(means, team(g))
+	}
+
+	rated := make([][]*trueskill.Rating, 0, len(groups))
+	for i, g := range groups {
+		delta := 0.0
+		for j := range groups {
+			if j != i {
+				delta += m.Score(i, j) - e.expected(means[i], means[j])
+			}
+		}
+		delta *= e.k / float64(len(groups)-1)
+
+		ratedGroup := make([]*trueskill.Rating, 0, len(g))
+		for _, r := range g {
+			ratedGroup = append(ratedGroup, trueskill.NewRating(r.Mu+delta, r.Sigma, r.Weight))
+		}
+		rated = append(rated, ratedGroup)
+	}
+
+	return trueskill.ByPlayer(m, rated), nil
+}
+
+// WinProbability returns the expected score of team a against team b. Elo
+// does not model draws, so it is also the probability that a wins.
+func (e *Elo) WinProbability(a []*trueskill.Rating, b []*trueskill.Rating) float64 {
+	return e.expected(team(a), team(b))
+}
+
+// Quality returns the mean of 4p(1-p) over pairs of teams, where p is the
+// win probability. It is 1 if all teams are equal.
+func (e *Elo) Quality(ratingGroups [][]*trueskill.Rating) (float64, error) {
+	if len(ratingGroups) < 2 {
+		return 0, errors.New("need multiple rating groups")
+	}
+	for _, g := range ratingGroups {
+		if len(g) < 1 {
+			return 0, errors.New("each group must contain ratings")
+		}
+	}
+
+	sum := 0.0
+	pairs := 0
+	for i := range ratingGroups {
+		for j := i + 1; j < len(ratingGroups); j++ {
+			p := e.WinProbability(ratingGroups[i], ratingGroups[j])
+			sum += 4 * p * (1 - p)
+			pairs++
+		}
+	}
+
+	return sum / float64(pairs), nil
+}
+
+// Expose returns the rating itself.
+func (e *Elo) Expose(r *trueskill.Rating) float64 {
+	return r.Mu
+}
@@ -0,0 +1,58 @@
+package elo_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/elo"
+	"github.com/gami/go-trueskill/evaluation"
+)
+
+func TestRateMatch(t *testing.T) {
+	e := elo.New()
+	m := &trueskill.Match{ID: "m", Teams: [][]string{{"a"}, {"b"}}}
+
+	rated, err := e.RateMatch(m, map[string]*trueskill.Rating{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rated["a"].Mu != 1516 || rated["b"].Mu != 1484 {
+		t.Errorf("got %v %v", rated["a"].Mu, rated["b"].Mu)
+	}
+
+	m.Ranks = []int{0, 0}
+	rated, err = e.RateMatch(m, map[string]*trueskill.Rating{
+		"a": trueskill.NewRating(1600, 0, 1),
+		"b": trueskill.NewRating(1400, 0, 1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rated["a"].Mu >= 1600 || math.Abs(rated["a"].Mu-1600+rated["b"].Mu-1400) > 1e-9 {
+		t.Errorf("draw must move the ratings closer: %v %v", rated["a"].Mu, rated["b"].Mu)
+	}
+}
+
+func TestRater(t *testing.T) {
+	matches := []*trueskill.Match{
+		{ID: "1", Teams: [][]string{{"a"}, {"b"}}},
+		{ID: "2", Teams: [][]string{{"a"}, {"c"}}},
+		{ID: "3", Teams: [][]string{{"b"}, {"c"}}},
+		{ID: "4", Teams: [][]string{{"a"}, {"b"}}},
+	}
+
+	var r trueskill.Rater = elo.New()
+	report, err := evaluation.Evaluate(r, matches)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Predictions) != 4 || report.Predictions[3].Probability <= 0.5 {
+		t.Errorf("got %+v", report.Predictions)
+	}
+
+	q, err := r.Quality([][]*trueskill.Rating{{r.CreateRating()}, {r.CreateRating()}})
+	if err != nil || q != 1 {
+		t.Errorf("got %v, %v", q, err)
+	}
+}
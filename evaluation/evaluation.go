@@ -0,0 +1,175 @@
+// Package evaluation measures how well a rating system predicts match outcomes.
+package evaluation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/replay"
+)
+
+// minProbability bounds predicted probabilities to keep the log loss finite.
+const minProbability = 1e-12
+
+// Prediction is a predicted probability that the team with the lower index
+// beats the other, and the observed outcome: 1 for a win, 0 for a loss and
+// 0.5 for a draw.
+type Prediction struct {
+	MatchID     string  `json:"match_id"`
+	Team        int     `json:"team"`
+	Opponent    int     `json:"opponent"`
+	Probability float64 `json:"probability"`
+	Outcome     float64 `json:"outcome"`
+}
+
+// Bin is a row of the calibration table.
+type Bin struct {
+	Lower         float64 `json:"lower"`
+	Upper         float64 `json:"upper"`
+	Count         int     `json:"count"`
+	MeanPredicted float64 `json:"mean_predicted"`
+	Observed      float64 `json:"observed"` // the mean outcome.
+}
+
+// Report is the predictive performance over a history.
+type Report struct {
+	Predictions []Prediction `json:"predictions"`
+	LogLoss     float64      `json:"log_loss"`
+	Brier       float64      `json:"brier"`
+	Accuracy    float64      `json:"accuracy"` // the ratio of correct predictions among non-draws.
+	Calibration []Bin        `json:"calibration"`
+}
+
+type config struct {
+	bins int
+}
+
+type option func(*config)
+
+// Bins is the number of bins of the calibration table. The default is 10.
+func Bins(n int) option {
+	return func(c *config) {
+		c.bins = n
+	}
+}
+
+// Evaluate replays the matches with the environment and predicts each
+// outcome between two teams before the match is rated. A draw is ignored
+// by the prediction, so the probability is of a win given no draw.
+func Evaluate(env trueskill.Rater, matches []*trueskill.Match, options ...option) (*Report, error) {
+	c := &config{
+		bins: 10,
+	}
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	if c.bins < 1 {
+		return nil, errors.New("need at least one bin")
+	}
+
+	predictions := make([]Prediction, 0, len(matches))
+	r := replay.New(env, replay.BeforeMatch(func(m *trueskill.Match, ratings map[string]*trueskill.Rating) error {
+		groups := trueskill.Groups(env, m, ratings)
+		for _, cst := range m.Constraints() {
+			team, opponent := cst.Winner, cst.Loser
+			outcome := 1.0
+			if cst.Draw {
+				outcome = 0.5
+			}
+			if team > opponent {
+				team, opponent = opponent, team
+				outcome = 1 - outcome
+			}
+
+			win := env.WinProbability(groups[team], groups[opponent])
+			lose := env.WinProbability(groups[opponent], groups[team])
+
+			predictions = append(predictions, Prediction{
+				MatchID:     m.ID,
+				Team:        team,
+				Opponent:    opponent,
+				Probability: win / (win + lose),
+				Outcome:     outcome,
+			})
+		}
+		return nil
+	}))
+
+	if _, err := r.Run(replay.FromSlice(matches)); err != nil {
+		return nil, err
+	}
+
+	return newReport(predictions, c.bins), nil
+}
+
+func newReport(predictions []Prediction, bins int) *Report {
+	r := &Report{
+		Predictions: predictions,
+		Calibration: make([]Bin, bins),
+	}
+
+	for i := range r.Calibration {
+		r.Calibration[i].Lower = float64(i) / float64(bins)
+		r.Calibration[i].Upper = float64(i+1) / float64(bins)
+	}
+
+	decided := 0
+	correct := 0
+	for _, p := range predictions {
+		q := math.Min(math.Max(p.Probability, minProbability), 1-minProbability)
+		r.LogLoss -= p.Outcome*math.Log(q) + (1-p.Outcome)*math.Log(1-q)
+		r.Brier += math.Pow(p.Probability-p.Outcome, 2)
+
+		if p.Outcome != 0.5 {
+			decided++
+			if (p.Probability > 0.5) == (p.Outcome == 1) {
+				correct++
+			}
+		}
+
+		i := int(p.Probability * float64(bins))
+		if i >= bins {
+			i = bins - 1
+		}
+		r.Calibration[i].Count++
+		r.Calibration[i].MeanPredicted += p.Probability
+		r.Calibration[i].Observed += p.Outcome
+	}
+
+	if len(predictions) > 0 {
+		r.LogLoss /= float64(len(predictions))
+		r.Brier /= float64(len(predictions))
+	}
+	if decided > 0 {
+		r.Accuracy = float64(correct) / float64(decided)
+	}
+	for i := range r.Calibration {
+		if n := r.Calibration[i].Count; n > 0 {
+			r.Calibration[i].MeanPredicted /= float64(n)
+			r.Calibration[i].Observed /= float64(n)
+		}
+	}
+
+	return r
+}
+
+func (r *Report) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "predictions: %d\n", len(r.Predictions))
+	fmt.Fprintf(&b, "log loss: %f\n", r.LogLoss)
+	fmt.Fprintf(&b, "brier: %f\n", r.Brier)
+	fmt.Fprintf(&b, "accuracy: %f\n", r.Accuracy)
+	fmt.Fprintf(&b, "calibration:\n")
+	for _, bin := range r.Calibration {
+		fmt.Fprintf(&b, "  [%.2f, %.2f) count=%d predicted=%f observed=%f\n",
+			bin.Lower, bin.Upper, bin.Count, bin.MeanPredicted, bin.Observed)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,57 @@
+package evaluation_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/evaluation"
+)
+
+func TestEvaluate(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	matches := make([]*trueskill.Match, 0)
+	for i := 0; i < 300; i++ {
+		a, b := rnd.Intn(10), rnd.Intn(10)
+		if a == b {
+			continue
+		}
+		// The player with the larger index mostly wins.
+		ranks := []int{1, 0}
+		if (a > b) != (rnd.Float64() < 0.2) {
+			ranks = []int{0, 1}
+		}
+		matches = append(matches, &trueskill.Match{
+			ID:    fmt.Sprint(i),
+			Time:  time.Unix(int64(i), 0),
+			Teams: [][]string{{fmt.Sprint(a)}, {fmt.Sprint(b)}},
+			Ranks: ranks,
+		})
+	}
+
+	r, err := evaluation.Evaluate(trueskill.NewTrueSkill(), matches, evaluation.Bins(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Predictions) != len(matches) {
+		t.Fatalf("got %d predictions for %d matches", len(r.Predictions), len(matches))
+	}
+	if r.Accuracy <= 0.6 {
+		t.Errorf("accuracy %v must be better than chance", r.Accuracy)
+	}
+	if r.LogLoss >= math.Log(2) || r.Brier >= 0.25 {
+		t.Errorf("log loss %v and brier %v must be better than a coin", r.LogLoss, r.Brier)
+	}
+
+	count := 0
+	for _, bin := range r.Calibration {
+		count += bin.Count
+	}
+	if len(r.Calibration) != 5 || count != len(matches) {
+		t.Errorf("calibration must count every prediction in 5 bins, got %+v", r.Calibration)
+	}
+}
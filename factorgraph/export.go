@@ -0,0 +1,171 @@
+package factorgraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/gami/go-trueskill/mathmatics"
+)
+
+// Graph is a named set of variables and factors which can be exported for debugging.
+type Graph struct {
+	variables     []*Variable
+	variableNames map[*Variable]string
+	factors       []Factor
+	factorNames   map[Factor]string
+}
+
+func NewGraph() *Graph {
+	return &Graph{
+		variableNames: make(map[*Variable]string),
+		factorNames:   make(map[Factor]string),
+	}
+}
+
+func (g *Graph) AddVariable(name string, v *Variable) {
+	if _, ok := g.variableNames[v]; ok {
+		return
+	}
+	g.variables = append(g.variables, v)
+	g.variableNames[v] = name
+}
+
+// AddFactor adds the factor. Its variables must be added before.
+func (g *Graph) AddFactor(name string, f Factor) {
+	if _, ok := g.factorNames[f]; ok {
+		return
+	}
+	g.factors = append(g.factors, f)
+	g.factorNames[f] = name
+}
+
+// Value is a Gaussian exported by mean and standard deviation.
+// Mu and Sigma are nil when the precision is zero.
+type Value struct {
+	Pi    float64  `json:"pi"`
+	Tau   float64  `json:"tau"`
+	Mu    *float64 `json:"mu,omitempty"`
+	Sigma *float64 `json:"sigma,omitempty"`
+}
+
+func newValue(g *mathmatics.Gaussian) Value {
+	v := Value{
+		Pi:  g.Pi,
+		Tau: g.Tau,
+	}
+	if g.Pi != 0 {
+		mu := g.Mu()
+		sigma := g.Sigma()
+		v.Mu = &mu
+		v.Sigma = &sigma
+	}
+	return v
+}
+
+func (v Value) String() string {
+	if v.Mu == nil {
+		return "N(-, inf)"
+	}
+	return fmt.Sprintf("N(%.3f, %.3f)", *v.Mu, *v.Sigma)
+}
+
+// Snapshot is the state of a graph at some point of the message passing.
+type Snapshot struct {
+	Iteration int             `json:"iteration"`
+	Variables []VariableState `json:"variables"`
+	Factors   []string        `json:"factors"`
+	Edges     []EdgeState     `json:"edges"`
+}
+
+type VariableState struct {
+	Name  string `json:"name"`
+	Value Value  `json:"value"`
+}
+
+// EdgeState is the message which the factor sends to the variable.
+type EdgeState struct {
+	Factor   string `json:"factor"`
+	Variable string `json:"variable"`
+	Message  Value  `json:"message"`
+}
+
+// Snapshot captures the current values of variables and messages.
+func (g *Graph) Snapshot(iteration int) *Snapshot {
+	s := &Snapshot{
+		Iteration: iteration,
+		Variables: make([]VariableState, 0, len(g.variables)),
+		Factors:   make([]string, 0, len(g.factors)),
+	}
+
+	for _, v := range g.variables {
+		s.Variables = append(s.Variables, VariableState{
+			Name:  g.variableNames[v],
+			Value: newValue(v.Gaussian),
+		})
+	}
+
+	for _, f := range g.factors {
+		name := g.factorNames[f]
+		s.Factors = append(s.Factors, name)
+
+		for _, v := range f.Variables() {
+			msg, ok := v.messages[f]
+			if !ok {
+				continue
+			}
+			s.Edges = append(s.Edges, EdgeState{
+				Factor:   name,
+				Variable: g.variableNames[v],
+				Message:  newValue(msg),
+			})
+		}
+	}
+
+	return s
+}
+
+// WriteJSON writes the current state of the graph as JSON.
+func (g *Graph) WriteJSON(w io.Writer) error {
+	return g.Snapshot(0).WriteJSON(w)
+}
+
+// WriteDOT writes the current state of the graph in the Graphviz DOT language.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	return g.Snapshot(0).WriteDOT(w)
+}
+
+func (s *Snapshot) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(s)
+}
+
+func (s *Snapshot) WriteDOT(w io.Writer) error {
+	ew := &errWriter{w: w}
+
+	ew.printf("graph factorgraph {\n")
+	ew.printf("  label=%q;\n", fmt.Sprintf("iteration %d", s.Iteration))
+	for _, v := range s.Variables {
+		ew.printf("  %q [shape=ellipse, label=%q];\n", "v:"+v.Name, v.Name+"\n"+v.Value.String())
+	}
+	for _, f := range s.Factors {
+		ew.printf("  %q [shape=box, label=%q];\n", "f:"+f, f)
+	}
+	for _, e := range s.Edges {
+		ew.printf("  %q -- %q [label=%q];\n", "f:"+e.Factor, "v:"+e.Variable, e.Message.String())
+	}
+	ew.printf("}\n")
+
+	return ew.err
+}
+
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = fmt.Fprintf(ew.w, format, args...)
+}
@@ -8,6 +8,7 @@ type Factor interface {
 	Up() (float64, error)
 	Down() float64
 	Var() *Variable
+	Variables() []*Variable
 }
 
 type FactorBase struct {
@@ -41,3 +42,7 @@ func (f *FactorBase) Var() *Variable {
 
 	return f.Vars[0]
 }
+
+func (f *FactorBase) Variables() []*Variable {
+	return f.Vars
+}
@@ -12,6 +12,9 @@ type TruncateFactor struct {
 	vFunc      func(a float64, b float64) float64
 	wFunc      func(a float64, b float64) (float64, error)
 	drawMargin float64
+	lastV      float64
+	lastW      float64
+	damping    float64
 }
 
 func NewTruncateFactor(
@@ -42,8 +45,30 @@ func (f *TruncateFactor) Up() (float64, error) {
 	if err != nil {
 		return 0, err
 	}
+	f.lastV = v
+	f.lastW = w
 	denom := 1.0 - w
 	pi := div.Pi / denom
 	tau := (div.Tau + (sqrtPi * v)) / denom
+
+	if f.damping > 0 && msg.Pi != 0 {
+		// Keep a part of the previous message instead of replacing the value.
+		newMsg := mathmatics.NewGaussian(pi, tau).Divide(div)
+		return val.updateMessage(f, mathmatics.NewGaussian(
+			(1-f.damping)*newMsg.Pi+f.damping*msg.Pi,
+			(1-f.damping)*newMsg.Tau+f.damping*msg.Tau,
+		)), nil
+	}
+
 	return val.updateValue(f, NewVariable(mathmatics.NewGaussian(pi, tau))), nil
 }
+
+// SetDamping keeps the given weight of the previous message on each Up.
+func (f *TruncateFactor) SetDamping(d float64) {
+	f.damping = d
+}
+
+// VW returns the v and w values calculated by the last Up.
+func (f *TruncateFactor) VW() (float64, float64) {
+	return f.lastV, f.lastW
+}
@@ -18,6 +18,11 @@ func NewVariable(g *mathmatics.Gaussian) *Variable {
 	}
 }
 
+// Message returns the message which the factor sent to the variable.
+func (v *Variable) Message(factor Factor) *mathmatics.Gaussian {
+	return v.messages[factor]
+}
+
 func (v *Variable) set(other *Variable) float64 {
 	delta := v.delta(other)
 	v.Pi = other.Pi
@@ -51,3 +56,33 @@ func (v *Variable) updateValue(factor Factor, val *Variable) float64 {
 
 	return v.set(val)
 }
+
+// State is a saved value and messages of a variable.
+type State struct {
+	value    mathmatics.Gaussian
+	messages map[Factor]mathmatics.Gaussian
+}
+
+// Save returns the current state of the variable.
+func (v *Variable) Save() *State {
+	s := &State{
+		value:    *v.Gaussian,
+		messages: make(map[Factor]mathmatics.Gaussian, len(v.messages)),
+	}
+	for f, msg := range v.messages {
+		s.messages[f] = *msg
+	}
+	return s
+}
+
+// Restore sets the variable back to the saved state. Messages from factors
+// connected after Save are dropped.
+func (v *Variable) Restore(s *State) {
+	v.Pi = s.value.Pi
+	v.Tau = s.value.Tau
+	v.messages = make(map[Factor]*mathmatics.Gaussian, len(s.messages))
+	for f, msg := range s.messages {
+		m := msg
+		v.messages[f] = &m
+	}
+}
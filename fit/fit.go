@@ -0,0 +1,214 @@
+// Package fit searches TrueSkill parameters which explain a match history best.
+package fit
+
+import (
+	"errors"
+	"math"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/replay"
+)
+
+// minProbability bounds the probability of an outcome to keep the log finite.
+const minProbability = 1e-12
+
+// Params are parameters of a TrueSkill environment.
+type Params struct {
+	Mu              float64 `json:"mu"`
+	Sigma           float64 `json:"sigma"`
+	Beta            float64 `json:"beta"`
+	Tau             float64 `json:"tau"`
+	DrawProbability float64 `json:"draw_probability"`
+}
+
+// DefaultParams returns the default parameters of trueskill.NewTrueSkill.
+func DefaultParams() Params {
+	return Params{
+		Mu:              25,
+		Sigma:           25.0 / 3,
+		Beta:            25.0 / 6,
+		Tau:             25.0 / 300,
+		DrawProbability: 0.1,
+	}
+}
+
+// TrueSkill makes an environment with the parameters.
+func (p Params) TrueSkill() *trueskill.TrueSkill {
+	return trueskill.NewTrueSkill(
+		trueskill.MU(p.Mu),
+		trueskill.Sigma(p.Sigma),
+		trueskill.Beta(p.Beta),
+		trueskill.Tau(p.Tau),
+		trueskill.DrawProbability(p.DrawProbability),
+	)
+}
+
+// LogLikelihood replays the matches with the environment and returns the sum
+// of log probabilities of each outcome predicted before it is rated.
+func LogLikelihood(env *trueskill.TrueSkill, matches []*trueskill.Match) (float64, error) {
+	sum := 0.0
+	r := replay.New(env, replay.BeforeMatch(func(m *trueskill.Match, ratings map[string]*trueskill.Rating) error {
+		p := env.Likelihood(env.Groups(m, ratings), m.Constraints())
+		sum += math.Log(math.Max(p, minProbability))
+		return nil
+	}))
+
+	if _, err := r.Run(replay.FromSlice(matches)); err != nil {
+		return 0, err
+	}
+
+	return sum, nil
+}
+
+// Evaluation is the objective at some parameters.
+type Evaluation struct {
+	Params        Params  `json:"params"`
+	LogLikelihood float64 `json:"log_likelihood"`
+}
+
+// Result is the best parameters found and every evaluation in order.
+type Result struct {
+	Params        Params       `json:"params"`
+	LogLikelihood float64      `json:"log_likelihood"`
+	Curve         []Evaluation `json:"curve"`
+}
+
+type config struct {
+	rounds  int
+	step    float64
+	minStep float64
+}
+
+type option func(*config)
+
+// Rounds limits the number of coordinate search rounds. The default is 20.
+func Rounds(n int) option {
+	return func(c *config) {
+		c.rounds = n
+	}
+}
+
+// Step is the initial factor by which a parameter is scaled in the search.
+// The default is 2.
+func Step(v float64) option {
+	return func(c *config) {
+		c.step = v
+	}
+}
+
+// param is a searchable parameter. Parameters are searched in the log scale,
+// or in the logit scale for the draw probability, so that they stay valid.
+type param struct {
+	get func(p *Params) float64
+	set func(p *Params, v float64)
+	to  func(v float64) float64
+	of  func(v float64) float64
+}
+
+func logit(v float64) float64 {
+	return math.Log(v / (1 - v))
+}
+
+func logistic(v float64) float64 {
+	return 1 / (1 + math.Exp(-v))
+}
+
+var params = []param{
+	{
+		get: func(p *Params) float64 { return p.Sigma },
+		set: func(p *Params, v float64) { p.Sigma = v },
+		to:  math.Log,
+		of:  math.Exp,
+	},
+	{
+		get: func(p *Params) float64 { return p.Beta },
+		set: func(p *Params, v float64) { p.Beta = v },
+		to:  math.Log,
+		of:  math.Exp,
+	},
+	{
+		get: func(p *Params) float64 { return p.Tau },
+		set: func(p *Params, v float64) { p.Tau = v },
+		to:  math.Log,
+		of:  math.Exp,
+	},
+	{
+		get: func(p *Params) float64 { return p.DrawProbability },
+		set: func(p *Params, v float64) { p.DrawProbability = v },
+		to:  logit,
+		of:  logistic,
+	},
+}
+
+// Fit searches parameters maximizing the predictive log likelihood of the
+// matches by coordinate search from the initial parameters. Mu is kept as
+// initial because a shift of every rating does not change any prediction.
+// Parameters which make Rate fail are skipped.
+func Fit(matches []*trueskill.Match, initial Params, options ...option) (*Result, error) {
+	c := &config{
+		rounds:  20,
+		step:    2,
+		minStep: 1.01,
+	}
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	if c.step <= 1 {
+		return nil, errors.New("step must be greater than 1")
+	}
+
+	if initial.Sigma <= 0 || initial.Beta <= 0 || initial.Tau <= 0 {
+		return nil, errors.New("sigma, beta and tau must be positive")
+	}
+
+	if initial.DrawProbability <= 0 || initial.DrawProbability >= 1 {
+		return nil, errors.New("draw probability must be between 0 and 1")
+	}
+
+	res := &Result{}
+
+	evaluate := func(p Params) (float64, error) {
+		ll, err := LogLikelihood(p.TrueSkill(), matches)
+		if err != nil {
+			return 0, err
+		}
+		res.Curve = append(res.Curve, Evaluation{Params: p, LogLikelihood: ll})
+		return ll, nil
+	}
+
+	best, err := evaluate(initial)
+	if err != nil {
+		return nil, err
+	}
+	res.Params = initial
+
+	step := math.Log(c.step)
+	for round := 0; round < c.rounds && step >= math.Log(c.minStep); round++ {
+		improved := false
+
+		for _, prm := range params {
+			for _, dir := range []float64{1, -1} {
+				p := res.Params
+				prm.set(&p, prm.of(prm.to(prm.get(&p))+dir*step))
+
+				ll, err := evaluate(p)
+				if err == nil && ll > best {
+					best = ll
+					res.Params = p
+					improved = true
+					break
+				}
+			}
+		}
+
+		if !improved {
+			step /= 2
+		}
+	}
+
+	res.LogLikelihood = best
+
+	return res, nil
+}
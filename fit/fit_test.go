@@ -0,0 +1,60 @@
+package fit_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/fit"
+)
+
+// history makes matches where the player with the larger index mostly wins.
+func history() []*trueskill.Match {
+	rnd := rand.New(rand.NewSource(1))
+	matches := make([]*trueskill.Match, 0)
+	for i := 0; i < 200; i++ {
+		a, b := rnd.Intn(8), rnd.Intn(8)
+		if a == b {
+			continue
+		}
+		teams := [][]string{{fmt.Sprint(a)}, {fmt.Sprint(b)}}
+		if (a < b) != (rnd.Float64() < 0.1) {
+			teams[0], teams[1] = teams[1], teams[0]
+		}
+		matches = append(matches, &trueskill.Match{
+			ID:    fmt.Sprint(i),
+			Time:  time.Unix(int64(i), 0),
+			Teams: teams,
+		})
+	}
+	return matches
+}
+
+func TestFit(t *testing.T) {
+	initial := fit.DefaultParams()
+	initial.Beta = 20
+
+	res, err := fit.Fit(history(), initial, fit.Rounds(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Curve) < 2 || res.Curve[0].Params != initial {
+		t.Fatalf("curve must start from the initial params, got %+v", res.Curve)
+	}
+	if res.LogLikelihood <= res.Curve[0].LogLikelihood {
+		t.Errorf("fitted log likelihood %v must improve on initial %v", res.LogLikelihood, res.Curve[0].LogLikelihood)
+	}
+	if res.Params.DrawProbability >= initial.DrawProbability {
+		t.Errorf("fitted draw probability %v must shrink from %v without draws", res.Params.DrawProbability, initial.DrawProbability)
+	}
+	if res.Params.Mu != initial.Mu {
+		t.Errorf("mu must be kept, got %v", res.Params.Mu)
+	}
+
+	if _, err := fit.Fit(history(), fit.Params{}); err == nil {
+		t.Error("zero params must be an error")
+	}
+}
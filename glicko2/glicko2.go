@@ -0,0 +1,228 @@
+// Package glicko2 implements the Glicko-2 rating system as a trueskill.Rater.
+package glicko2
+
+import (
+	"errors"
+	"math"
+
+	"github.com/gami/go-trueskill"
+)
+
+// scale converts ratings between the Glicko scale and the Glicko-2 scale.
+const scale = 173.7178
+
+// epsilon is the tolerance of the volatility iteration.
+const epsilon = 0.000001
+
+// Glicko2 represents environment of the Glicko-2 rating. Ratings use Mu as the
+// rating, Sigma as the rating deviation and Volatility.
+type Glicko2 struct {
+	initial    float64 // the rating of a new player.
+	deviation  float64 // the rating deviation of a new player.
+	volatility float64 // the volatility of a new player.
+	tau        float64 // the system constant which restrains changes of volatilities.
+}
+
+type option func(*Glicko2)
+
+func New(options ...option) *Glicko2 {
+	g := &Glicko2{
+		initial:    1500,
+		deviation:  350,
+		volatility: 0.06,
+		tau:        0.5,
+	}
+
+	for _, opt := range options {
+		opt(g)
+	}
+
+	return g
+}
+
+// Initial is the rating, the rating deviation and the volatility of a new
+// player. The default is 1500, 350 and 0.06.
+func Initial(rating float64, deviation float64, volatility float64) option {
+	return func(g *Glicko2) {
+		g.initial = rating
+		g.deviation = deviation
+		g.volatility = volatility
+	}
+}
+
+// Tau is the system constant which restrains changes of volatilities.
+// Reasonable values are between 0.3 and 1.2. The default is 0.5.
+func Tau(v float64) option {
+	return func(g *Glicko2) {
+		g.tau = v
+	}
+}
+
+var _ trueskill.Rater = (*Glicko2)(nil)
+
+func (g *Glicko2) CreateRating() *trueskill.Rating {
+	r := trueskill.NewRating(g.initial, g.deviation, 1)
+	r.Volatility = g.volatility
+	return r
+}
+
+// team returns the mean rating and the root mean square deviation of the
+// team in the Glicko-2 scale.
+func (g *Glicko2) team(ratings []*trueskill.Rating) (float64, float64) {
+	mu := 0.0
+	phi := 0.0
+	for _, r := range ratings {
+		mu += (r.Mu - g.initial) / scale
+		phi += math.Pow(r.Sigma/scale, 2)
+	}
+	n := float64(len(ratings))
+	return mu / n, math.Sqrt(phi / n)
+}
+
+func weight(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+func expected(mu float64, opponentMu float64, opponentPhi float64) float64 {
+	return 1 / (1 + math.Exp(-weight(opponentPhi)*(mu-opponentMu)))
+}
+
+// RateMatch treats the match as a rating period of its players. Each player
+// is rated against every opponent team as a single player of the mean rating
+// and the root mean square deviation of the team. The new volatilities are
+// in the returned ratings, so rating the same input always gives the same
+// output.
+func (g *Glicko2) RateMatch(m *trueskill.Match, ratings map[string]*trueskill.Rating) (map[string]*trueskill.Rating, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	groups := trueskill.Groups(g, m, ratings)
+	mus := make([]float64, 0, len(groups))
+	phis := make([]float64, 0, len(groups))
+	for _, group := range groups {
+		mu, phi := g.team(group)
+		mus = append(mus, mu)
+		phis = append(phis, phi)
+	}
+
+	rated := make([][]*trueskill.Rating, 0, len(groups))
+	for i, group := range groups {
+		ratedGroup := make([]*trueskill.Rating, 0, len(group))
+		for _, r := range group {
+			ratedGroup = append(ratedGroup, g.update(r, m, i, mus, phis))
+		}
+		rated = append(rated, ratedGroup)
+	}
+
+	return trueskill.ByPlayer(m, rated), nil
+}
+
+// update runs the steps of the Glicko-2 algorithm for a player of team i.
+func (g *Glicko2) update(r *trueskill.Rating, m *trueskill.Match, i int, mus []float64, phis []float64) *trueskill.Rating {
+	mu := (r.Mu - g.initial) / scale
+	phi := r.Sigma / scale
+	sigma := r.Volatility
+	if sigma <= 0 {
+		sigma = g.volatility
+	}
+
+	v := 0.0
+	sum := 0.0
+	for j := range mus {
+		if j == i {
+			continue
+		}
+		w := weight(phis[j])
+		e := expected(mu, mus[j], phis[j])
+		v += w * w * e * (1 - e)
+		sum += w * (m.Score(i, j) - e)
+	}
+	v = 1 / v
+	delta := v * sum
+
+	sigma = g.volatilityOf(phi, sigma, v, delta)
+
+	phiStar := math.Sqrt(phi*phi + sigma*sigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*sum
+
+	rated := trueskill.NewRating(newMu*scale+g.initial, newPhi*scale, r.Weight)
+	rated.Volatility = sigma
+	return rated
+}
+
+// volatilityOf finds the new volatility by the Illinois algorithm.
+func (g *Glicko2) volatilityOf(phi float64, sigma float64, v float64, delta float64) float64 {
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+		return ex*(delta*delta-phi*phi-v-ex)/(2*d*d) - (x-a)/(g.tau*g.tau)
+	}
+
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*g.tau) < 0 {
+			k++
+		}
+		B = a - k*g.tau
+	}
+
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > epsilon {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+
+	return math.Exp(A / 2)
+}
+
+// WinProbability returns the expected score of team a against team b.
+// Glicko-2 does not model draws, so it is also the probability that a wins.
+func (g *Glicko2) WinProbability(a []*trueskill.Rating, b []*trueskill.Rating) float64 {
+	muA, phiA := g.team(a)
+	muB, phiB := g.team(b)
+	return expected(muA, muB, math.Sqrt(phiA*phiA+phiB*phiB))
+}
+
+// Quality returns the mean of 4p(1-p) over pairs of teams, where p is the
+// win probability. It is 1 if all teams are equal.
+func (g *Glicko2) Quality(ratingGroups [][]*trueskill.Rating) (float64, error) {
+	if len(ratingGroups) < 2 {
+		return 0, errors.New("need multiple rating groups")
+	}
+	for _, group := range ratingGroups {
+		if len(group) < 1 {
+			return 0, errors.New("each group must contain ratings")
+		}
+	}
+
+	sum := 0.0
+	pairs := 0
+	for i := range ratingGroups {
+		for j := i + 1; j < len(ratingGroups); j++ {
+			p := g.WinProbability(ratingGroups[i], ratingGroups[j])
+			sum += 4 * p * (1 - p)
+			pairs++
+		}
+	}
+
+	return sum / float64(pairs), nil
+}
+
+// Expose returns the rating less twice the rating deviation, which is below
+// the true rating with about 98% confidence.
+func (g *Glicko2) Expose(r *trueskill.Rating) float64 {
+	return r.Mu - 2*r.Sigma
+}
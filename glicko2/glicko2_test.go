@@ -0,0 +1,49 @@
+package glicko2_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/glicko2"
+)
+
+// TestRateMatch follows the example in Glickman, "Example of the Glicko-2
+// system". The player beats the first opponent and loses to the others.
+func TestRateMatch(t *testing.T) {
+	g := glicko2.New()
+
+	player := trueskill.NewRating(1500, 200, 1)
+	player.Volatility = 0.06
+
+	m := &trueskill.Match{
+		ID:    "m",
+		Teams: [][]string{{"player"}, {"a"}, {"b"}, {"c"}},
+		Ranks: []int{1, 2, 0, 0},
+	}
+	rated, err := g.RateMatch(m, map[string]*trueskill.Rating{
+		"player": player,
+		"a":      trueskill.NewRating(1400, 30, 1),
+		"b":      trueskill.NewRating(1550, 100, 1),
+		"c":      trueskill.NewRating(1700, 300, 1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := rated["player"]
+	if math.Abs(got.Mu-1464.06) > 0.01 || math.Abs(got.Sigma-151.52) > 0.01 || math.Abs(got.Volatility-0.05999) > 0.00001 {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestWinProbability(t *testing.T) {
+	g := glicko2.New()
+	a := []*trueskill.Rating{trueskill.NewRating(1700, 50, 1)}
+	b := []*trueskill.Rating{trueskill.NewRating(1500, 50, 1)}
+
+	p := g.WinProbability(a, b)
+	if p <= 0.5 || math.Abs(p+g.WinProbability(b, a)-1) > 1e-12 {
+		t.Errorf("got %v", p)
+	}
+}
@@ -0,0 +1,53 @@
+package trueskill
+
+import (
+	"fmt"
+
+	"github.com/gami/go-trueskill/factorgraph"
+)
+
+// buildGraph names every node of the rating factor graph for the export.
+func buildGraph(
+	ratingVars []*factorgraph.Variable,
+	perfVars []*factorgraph.Variable,
+	teamPerfVars []*factorgraph.Variable,
+	teamDiffVars []*factorgraph.Variable,
+	ratingLayer []*factorgraph.PriorFactor,
+	perfLayer []factorgraph.Factor,
+	teamPerfLayer []*factorgraph.SumFactor,
+	teamDiffLayer []*factorgraph.SumFactor,
+	truncLayer []*factorgraph.TruncateFactor,
+) *factorgraph.Graph {
+	g := factorgraph.NewGraph()
+
+	for i, v := range ratingVars {
+		g.AddVariable(fmt.Sprintf("rating[%d]", i), v)
+	}
+	for i, v := range perfVars {
+		g.AddVariable(fmt.Sprintf("perf[%d]", i), v)
+	}
+	for i, v := range teamPerfVars {
+		g.AddVariable(fmt.Sprintf("team_perf[%d]", i), v)
+	}
+	for i, v := range teamDiffVars {
+		g.AddVariable(fmt.Sprintf("team_diff[%d]", i), v)
+	}
+
+	for i, f := range ratingLayer {
+		g.AddFactor(fmt.Sprintf("prior[%d]", i), f)
+	}
+	for i, f := range perfLayer {
+		g.AddFactor(fmt.Sprintf("likelihood[%d]", i), f)
+	}
+	for i, f := range teamPerfLayer {
+		g.AddFactor(fmt.Sprintf("team_sum[%d]", i), f)
+	}
+	for i, f := range teamDiffLayer {
+		g.AddFactor(fmt.Sprintf("diff_sum[%d]", i), f)
+	}
+	for i, f := range truncLayer {
+		g.AddFactor(fmt.Sprintf("trunc[%d]", i), f)
+	}
+
+	return g
+}
@@ -0,0 +1,106 @@
+package trueskill_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/factorgraph"
+)
+
+func TestInspectGraph(t *testing.T) {
+	env := trueskill.NewTrueSkill()
+	groups := [][]*trueskill.Rating{{env.CreateRating()}, {env.CreateRating()}, {env.CreateRating()}}
+
+	var trace trueskill.Trace
+	var snapshots []*factorgraph.Snapshot
+	var last *factorgraph.Graph
+	_, err := env.Rate(groups, trueskill.Explain(&trace), trueskill.InspectGraph(func(iteration int, g *factorgraph.Graph) {
+		snapshots = append(snapshots, g.Snapshot(iteration))
+		last = g
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A snapshot for each iteration and the final one.
+	if len(snapshots) != trace.Iterations+1 {
+		t.Fatalf("got %d snapshots for %d iterations", len(snapshots), trace.Iterations)
+	}
+	for i, s := range snapshots {
+		if s.Iteration != i {
+			t.Errorf("snapshot %d: got iteration %d", i, s.Iteration)
+		}
+	}
+
+	final := snapshots[len(snapshots)-1]
+	var variables []string
+	for _, layer := range []struct {
+		name string
+		n    int
+	}{{"rating", 3}, {"perf", 3}, {"team_perf", 3}, {"team_diff", 2}} {
+		for i := 0; i < layer.n; i++ {
+			variables = append(variables, fmt.Sprintf("%s[%d]", layer.name, i))
+		}
+	}
+	if len(final.Variables) != len(variables) {
+		t.Fatalf("got %d variables, want %d", len(final.Variables), len(variables))
+	}
+	for i, v := range final.Variables {
+		if v.Name != variables[i] {
+			t.Errorf("variable %d: got %s, want %s", i, v.Name, variables[i])
+		}
+	}
+
+	// The number of variables each factor of a layer is connected to.
+	edges := map[string]int{"prior": 1, "likelihood": 2, "team_sum": 2, "diff_sum": 3, "trunc": 1}
+	factors := map[string]int{"prior": 3, "likelihood": 3, "team_sum": 3, "diff_sum": 2, "trunc": 2}
+	got := make(map[string]int)
+	for _, f := range final.Factors {
+		got[f[:strings.Index(f, "[")]]++
+	}
+	for layer, n := range factors {
+		if got[layer] != n {
+			t.Errorf("%s: got %d factors, want %d", layer, got[layer], n)
+		}
+	}
+	perFactor := make(map[string]int)
+	for _, e := range final.Edges {
+		perFactor[e.Factor]++
+	}
+	for _, f := range final.Factors {
+		if want := edges[f[:strings.Index(f, "[")]]; perFactor[f] != want {
+			t.Errorf("%s: got %d edges, want %d", f, perFactor[f], want)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := final.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded factorgraph.Snapshot
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Iteration != final.Iteration || len(decoded.Edges) != len(final.Edges) {
+		t.Errorf("got %+v", decoded)
+	}
+
+	buf.Reset()
+	if err := last.WriteDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+	dot := buf.String()
+	if !strings.HasPrefix(dot, "graph factorgraph {\n") || !strings.HasSuffix(dot, "}\n") {
+		t.Errorf("got %s", dot)
+	}
+	if strings.Count(dot, "{") != strings.Count(dot, "}") || strings.Count(dot, " -- ") != len(final.Edges) {
+		t.Errorf("got %s", dot)
+	}
+	if strings.Count(dot, "shape=ellipse") != len(variables) || strings.Count(dot, "shape=box") != len(final.Factors) {
+		t.Errorf("got %s", dot)
+	}
+}
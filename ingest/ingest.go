@@ -0,0 +1,142 @@
+// Package ingest rates submitted match results exactly once and keeps them
+// for later queries.
+package ingest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/store"
+)
+
+// ErrNotFound is returned when a match has not been ingested.
+var ErrNotFound = errors.New("match not found")
+
+// markerPrefix starts the store markers which keep the results of applied
+// matches.
+const markerPrefix = "match:"
+
+// Result is the input and output of rating a match.
+type Result struct {
+	Match      *trueskill.Match         `json:"match"`
+	Before     map[string]*store.Record `json:"before"` // missing for new players.
+	After      map[string]*store.Record `json:"after"`
+	IngestedAt time.Time                `json:"ingested_at"`
+	Duplicate  bool                     `json:"-"` // the match had been ingested before this submission.
+}
+
+// Ingester rates matches into a store. The result of a match is kept in a
+// store marker written atomically with the ratings, so a retried submission
+// never rates the players twice, even across restarts or processes sharing
+// the store.
+type Ingester struct {
+	store  store.Store
+	rater  trueskill.Rater
+	reject bool
+	now    func() time.Time
+}
+
+type option func(*Ingester)
+
+func New(s store.Store, rater trueskill.Rater, options ...option) *Ingester {
+	i := &Ingester{
+		store: s,
+		rater: rater,
+		now:   time.Now,
+	}
+
+	for _, opt := range options {
+		opt(i)
+	}
+
+	return i
+}
+
+// RejectDuplicates makes Ingest fail with store.ErrDuplicate for a match
+// ingested before. By default it returns the earlier result.
+func RejectDuplicates() option {
+	return func(i *Ingester) {
+		i.reject = true
+	}
+}
+
+// WithClock sets the function which tells the time of ingestion.
+func WithClock(now func() time.Time) option {
+	return func(i *Ingester) {
+		i.now = now
+	}
+}
+
+// Ingest rates the match unless it has been ingested. A duplicate returns
+// the earlier result with Duplicate set, or an error if its content differs.
+func (i *Ingester) Ingest(m *trueskill.Match) (*Result, error) {
+	if m.ID == "" {
+		return nil, errors.New("match must have an ID")
+	}
+
+	var r *Result
+	applied, err := store.Apply(i.store, i.rater, m, store.Claim(markerPrefix+m.ID, func(a *store.Applied) ([]byte, error) {
+		r = &Result{
+			Match:      m,
+			Before:     a.Before,
+			After:      a.After,
+			IngestedAt: i.now(),
+		}
+		return json.Marshal(r)
+	}))
+	if errors.Is(err, store.ErrDuplicate) {
+		return i.duplicate(m, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r.After = applied.After
+	return r, nil
+}
+
+func (i *Ingester) duplicate(m *trueskill.Match, err error) (*Result, error) {
+	if i.reject {
+		return nil, err
+	}
+
+	earlier, getErr := i.Result(m.ID)
+	if errors.Is(getErr, ErrNotFound) {
+		// The marker is written atomically with the ratings, so it exists
+		// by now, but it may have been written without a result.
+		return &Result{Match: m, Duplicate: true}, nil
+	}
+	if getErr != nil {
+		return nil, getErr
+	}
+	if !reflect.DeepEqual(earlier.Match.Teams, m.Teams) || !reflect.DeepEqual(earlier.Match.Ranks, m.Ranks) {
+		return nil, fmt.Errorf("match %q was ingested with different teams or ranks", m.ID)
+	}
+
+	earlier.Duplicate = true
+	return earlier, nil
+}
+
+// Result returns the result of the ingested match or ErrNotFound.
+func (i *Ingester) Result(matchID string) (*Result, error) {
+	data, err := i.store.GetMarker(markerPrefix + matchID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, ErrNotFound
+	}
+
+	r := &Result{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("result of match %q: %w", matchID, err)
+	}
+	return r, nil
+}
@@ -0,0 +1,134 @@
+package ingest_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/ingest"
+	"github.com/gami/go-trueskill/store"
+)
+
+func TestIngestDuplicate(t *testing.T) {
+	s := store.NewMemoryStore()
+	in := ingest.New(s, trueskill.NewTrueSkill())
+
+	m := &trueskill.Match{ID: "m1", Teams: [][]string{{"a"}, {"b"}}}
+	first, err := in.Ingest(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	retried := &trueskill.Match{ID: "m1", Teams: [][]string{{"a"}, {"b"}}}
+	second, err := in.Ingest(retried)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.Duplicate || second.After["a"].Rating != first.After["a"].Rating {
+		t.Errorf("got %+v", second)
+	}
+
+	r, err := s.Get("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Version != 1 {
+		t.Errorf("a is rated %d times", r.Version)
+	}
+
+	if _, err := in.Ingest(&trueskill.Match{ID: "m1", Teams: [][]string{{"b"}, {"a"}}}); err == nil {
+		t.Error("duplicate with a different outcome must be an error")
+	}
+
+	got, err := in.Result("m1")
+	if err != nil || got.Before["a"] != nil || got.After["b"].Version != 1 {
+		t.Errorf("got %+v, %v", got, err)
+	}
+	if _, err := in.Result("m2"); !errors.Is(err, ingest.ErrNotFound) {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestIngestRejectDuplicates(t *testing.T) {
+	s := store.NewMemoryStore()
+	in := ingest.New(s, trueskill.NewTrueSkill(), ingest.RejectDuplicates())
+
+	m := &trueskill.Match{ID: "m1", Teams: [][]string{{"a"}, {"b"}}}
+	if _, err := in.Ingest(m); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another ingester sharing the store also sees the match as applied.
+	other := ingest.New(s, trueskill.NewTrueSkill(), ingest.RejectDuplicates())
+	for _, i := range []*ingest.Ingester{in, other} {
+		if _, err := i.Ingest(m); !errors.Is(err, store.ErrDuplicate) {
+			t.Errorf("got %v", err)
+		}
+	}
+}
+
+func TestIngestAfterRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ingest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ratings.log")
+
+	s, err := store.OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &trueskill.Match{ID: "m1", Teams: [][]string{{"a"}, {"b"}}}
+	first, err := ingest.New(s, trueskill.NewTrueSkill()).Ingest(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = store.OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	got, err := ingest.New(s, trueskill.NewTrueSkill()).Ingest(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Duplicate || got.After["a"].Rating != first.After["a"].Rating {
+		t.Errorf("got %+v", got)
+	}
+
+	// Only the players have ratings.
+	records, err := s.BatchGet([]string{"a", "b", "match:m1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records["a"].Version != 1 {
+		t.Errorf("got %+v", records)
+	}
+}
+
+func TestIngestDuplicateWithoutResult(t *testing.T) {
+	s := store.NewMemoryStore()
+	if _, err := s.CompareAndSwap(nil, store.Marker{Key: "match:m1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ingest.New(s, trueskill.NewTrueSkill()).Ingest(&trueskill.Match{ID: "m1", Teams: [][]string{{"a"}, {"b"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Duplicate || got.Before != nil || got.After != nil {
+		t.Errorf("got %+v", got)
+	}
+	if _, err := s.Get("a"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("got %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+// Package leaderboard orders players by the exposure of their ratings.
+package leaderboard
+
+import (
+	"sync"
+
+	"github.com/gami/go-trueskill"
+)
+
+// Entry is a player on the leaderboard. Rank starts from 1.
+type Entry struct {
+	PlayerID string  `json:"player_id"`
+	Score    float64 `json:"score"`
+	Rank     int     `json:"rank"`
+}
+
+// Leaderboard keeps players ordered by score. Players of an equal score are
+// ordered by ID.
+type Leaderboard struct {
+	score func(r *trueskill.Rating) float64
+
+	mu     sync.RWMutex
+	root   *node
+	scores map[string]float64
+}
+
+type option func(*Leaderboard)
+
+// New makes a leaderboard which scores players by Expose of the rater.
+func New(rater trueskill.Rater, options ...option) *Leaderboard {
+	l := &Leaderboard{
+		score:  rater.Expose,
+		scores: make(map[string]float64),
+	}
+
+	for _, opt := range options {
+		opt(l)
+	}
+
+	return l
+}
+
+// Score sets the function which scores a rating instead of Expose.
+func Score(fn func(r *trueskill.Rating) float64) option {
+	return func(l *Leaderboard) {
+		l.score = fn
+	}
+}
+
+// Update sets the rating of the player.
+func (l *Leaderboard) Update(id string, r *trueskill.Rating) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.update(id, r)
+}
+
+// UpdateAll sets the ratings by player ID, such as those returned by
+// RateMatch.
+func (l *Leaderboard) UpdateAll(ratings map[string]*trueskill.Rating) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for id, r := range ratings {
+		l.update(id, r)
+	}
+}
+
+func (l *Leaderboard) update(id string, r *trueskill.Rating) {
+	if old, ok := l.scores[id]; ok {
+		l.root = remove(l.root, key{score: old, id: id})
+	}
+	s := l.score(r)
+	l.scores[id] = s
+	l.root = insert(l.root, key{score: s, id: id})
+}
+
+// Remove takes the player off the leaderboard. It returns false if the
+// player is not on it.
+func (l *Leaderboard) Remove(id string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s, ok := l.scores[id]
+	if !ok {
+		return false
+	}
+	l.root = remove(l.root, key{score: s, id: id})
+	delete(l.scores, id)
+	return true
+}
+
+// Len returns the number of players.
+func (l *Leaderboard) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return size(l.root)
+}
+
+// Rank returns the entry of the player.
+func (l *Leaderboard) Rank(id string) (Entry, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	s, ok := l.scores[id]
+	if !ok {
+		return Entry{}, false
+	}
+	return Entry{PlayerID: id, Score: s, Rank: rank(l.root, key{score: s, id: id}) + 1}, true
+}
+
+// Top returns the best n players.
+func (l *Leaderboard) Top(n int) []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.entries(0, n)
+}
+
+// Around returns the player with up to n players above and n below. A
+// negative n counts as 0.
+func (l *Leaderboard) Around(id string, n int) []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	s, ok := l.scores[id]
+	if !ok {
+		return nil
+	}
+	if n < 0 {
+		n = 0
+	}
+	i := rank(l.root, key{score: s, id: id})
+	from := i - n
+	if from < 0 {
+		from = 0
+	}
+	return l.entries(from, i+n+1-from)
+}
+
+// entries returns up to n entries from the 0-based index.
+func (l *Leaderboard) entries(from int, n int) []Entry {
+	if n < 0 {
+		n = 0
+	}
+	to := from + n
+	if to > size(l.root) {
+		to = size(l.root)
+	}
+	if to < from {
+		to = from
+	}
+
+	entries := make([]Entry, 0, to-from)
+	for i := from; i < to; i++ {
+		k := at(l.root, i)
+		entries = append(entries, Entry{PlayerID: k.id, Score: k.score, Rank: i + 1})
+	}
+	return entries
+}
+
+// Percentile returns the percentage of players ranked below the player.
+func (l *Leaderboard) Percentile(id string) (float64, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	s, ok := l.scores[id]
+	if !ok {
+		return 0, false
+	}
+	n := size(l.root)
+	below := n - rank(l.root, key{score: s, id: id}) - 1
+	return 100 * float64(below) / float64(n), true
+}
@@ -0,0 +1,99 @@
+package leaderboard_test
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/leaderboard"
+)
+
+func TestLeaderboard(t *testing.T) {
+	env := trueskill.NewTrueSkill()
+	l := leaderboard.New(env)
+
+	rnd := rand.New(rand.NewSource(1))
+	ratings := make(map[string]*trueskill.Rating)
+	for i := 0; i < 500; i++ {
+		id := fmt.Sprintf("p%03d", rnd.Intn(200))
+		r := trueskill.NewRating(rnd.Float64()*50, 1+rnd.Float64()*7, 1)
+		ratings[id] = r
+		l.Update(id, r)
+	}
+	for i := 0; i < 50; i++ {
+		id := fmt.Sprintf("p%03d", i)
+		if l.Remove(id) {
+			delete(ratings, id)
+		}
+	}
+
+	ids := make([]string, 0, len(ratings))
+	for id := range ratings {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := env.Expose(ratings[ids[i]]), env.Expose(ratings[ids[j]])
+		if a != b {
+			return a > b
+		}
+		return ids[i] < ids[j]
+	})
+
+	if l.Len() != len(ids) {
+		t.Fatalf("got %d players, want %d", l.Len(), len(ids))
+	}
+	for i, id := range ids {
+		e, ok := l.Rank(id)
+		if !ok || e.Rank != i+1 {
+			t.Fatalf("%s: got rank %d, want %d", id, e.Rank, i+1)
+		}
+	}
+
+	top := l.Top(3)
+	if len(top) != 3 || top[0].PlayerID != ids[0] || top[2].Rank != 3 {
+		t.Errorf("got %+v", top)
+	}
+
+	around := l.Around(ids[10], 2)
+	got := make([]string, 0)
+	for _, e := range around {
+		got = append(got, e.PlayerID)
+	}
+	if !reflect.DeepEqual(got, ids[8:13]) {
+		t.Errorf("got %v, want %v", got, ids[8:13])
+	}
+	if around := l.Around(ids[0], 2); len(around) != 3 {
+		t.Errorf("got %+v", around)
+	}
+	if top := l.Top(-1); len(top) != 0 {
+		t.Errorf("got %+v", top)
+	}
+	if around := l.Around(ids[10], -2); len(around) != 1 || around[0].PlayerID != ids[10] {
+		t.Errorf("got %+v", around)
+	}
+
+	if p, _ := l.Percentile(ids[0]); p != 100*float64(len(ids)-1)/float64(len(ids)) {
+		t.Errorf("got %v", p)
+	}
+	if p, _ := l.Percentile(ids[len(ids)-1]); p != 0 {
+		t.Errorf("got %v", p)
+	}
+}
+
+func TestLeaderboardUpdateAll(t *testing.T) {
+	env := trueskill.NewTrueSkill()
+	l := leaderboard.New(env, leaderboard.Score(func(r *trueskill.Rating) float64 { return r.Mu }))
+
+	rated, err := env.RateMatch(&trueskill.Match{ID: "m", Teams: [][]string{{"a"}, {"b"}}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.UpdateAll(rated)
+
+	if e, _ := l.Rank("a"); e.Rank != 1 || e.Score != rated["a"].Mu {
+		t.Errorf("got %+v", e)
+	}
+}
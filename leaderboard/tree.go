@@ -0,0 +1,154 @@
+package leaderboard
+
+// key orders players by descending score, then by ascending ID.
+type key struct {
+	score float64
+	id    string
+}
+
+func (k key) less(other key) bool {
+	if k.score != other.score {
+		return k.score > other.score
+	}
+	return k.id < other.id
+}
+
+// node is a node of an AVL tree which knows the size of its subtree, so
+// that ranks are found in O(log n).
+type node struct {
+	key         key
+	left, right *node
+	height      int
+	size        int
+}
+
+func height(n *node) int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func size(n *node) int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func (n *node) update() {
+	n.height = 1 + max(height(n.left), height(n.right))
+	n.size = 1 + size(n.left) + size(n.right)
+}
+
+func max(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func rotateRight(n *node) *node {
+	l := n.left
+	n.left = l.right
+	l.right = n
+	n.update()
+	l.update()
+	return l
+}
+
+func rotateLeft(n *node) *node {
+	r := n.right
+	n.right = r.left
+	r.left = n
+	n.update()
+	r.update()
+	return r
+}
+
+func balance(n *node) *node {
+	n.update()
+	switch d := height(n.left) - height(n.right); {
+	case d > 1:
+		if height(n.left.left) < height(n.left.right) {
+			n.left = rotateLeft(n.left)
+		}
+		return rotateRight(n)
+	case d < -1:
+		if height(n.right.right) < height(n.right.left) {
+			n.right = rotateRight(n.right)
+		}
+		return rotateLeft(n)
+	}
+	return n
+}
+
+func insert(n *node, k key) *node {
+	if n == nil {
+		return &node{key: k, height: 1, size: 1}
+	}
+	if k.less(n.key) {
+		n.left = insert(n.left, k)
+	} else {
+		n.right = insert(n.right, k)
+	}
+	return balance(n)
+}
+
+func remove(n *node, k key) *node {
+	if n == nil {
+		return nil
+	}
+	switch {
+	case k.less(n.key):
+		n.left = remove(n.left, k)
+	case n.key.less(k):
+		n.right = remove(n.right, k)
+	default:
+		if n.left == nil {
+			return n.right
+		}
+		if n.right == nil {
+			return n.left
+		}
+		min := n.right
+		for min.left != nil {
+			min = min.left
+		}
+		n.key = min.key
+		n.right = remove(n.right, min.key)
+	}
+	return balance(n)
+}
+
+// rank returns the number of keys before k.
+func rank(n *node, k key) int {
+	r := 0
+	for n != nil {
+		if k.less(n.key) {
+			n = n.left
+		} else if n.key.less(k) {
+			r += size(n.left) + 1
+			n = n.right
+		} else {
+			return r + size(n.left)
+		}
+	}
+	return r
+}
+
+// at returns the key at the 0-based index i.
+func at(n *node, i int) key {
+	for {
+		l := size(n.left)
+		switch {
+		case i < l:
+			n = n.left
+		case i > l:
+			i -= l + 1
+			n = n.right
+		default:
+			return n.key
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package trueskill
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Match is a result of a game among teams of players identified by IDs.
+type Match struct {
+	ID    string
+	Time  time.Time
+	Teams [][]string // player IDs of each team.
+	Ranks []int      // rank of each team. Lower is better and equal ranks are a draw. Teams are sorted by rank if nil.
+}
+
+// Constraints converts the ranks of the match into outcomes between teams.
+func (m *Match) Constraints() []Constraint {
+	if m.Ranks == nil {
+		return chainConstraints(len(m.Teams))
+	}
+
+	order := make([]int, len(m.Teams))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return m.Ranks[order[a]] < m.Ranks[order[b]]
+	})
+
+	constraints := make([]Constraint, 0, len(order)-1)
+	for i := 0; i < len(order)-1; i++ {
+		w, l := order[i], order[i+1]
+		constraints = append(constraints, Constraint{
+			Winner: w,
+			Loser:  l,
+			Draw:   m.Ranks[w] == m.Ranks[l],
+		})
+	}
+
+	return constraints
+}
+
+// Players returns IDs of all players in the match.
+func (m *Match) Players() []string {
+	players := make([]string, 0)
+	for _, team := range m.Teams {
+		players = append(players, team...)
+	}
+	return players
+}
+
+// Validate checks that the match can be rated.
+func (m *Match) Validate() error {
+	if len(m.Teams) < 2 {
+		return errors.New("need multiple teams")
+	}
+
+	if m.Ranks != nil && len(m.Ranks) != len(m.Teams) {
+		return fmt.Errorf("got %d ranks for %d teams", len(m.Ranks), len(m.Teams))
+	}
+
+	seen := make(map[string]bool)
+	for _, team := range m.Teams {
+		if len(team) < 1 {
+			return errors.New("each team must contain players")
+		}
+		for _, id := range team {
+			if seen[id] {
+				return fmt.Errorf("player %s appears twice", id)
+			}
+			seen[id] = true
+		}
+	}
+
+	return nil
+}
+
+// Score returns the score of team i against team j in the match: 1 for a
+// win, 0.5 for a draw and 0 for a loss.
+func (m *Match) Score(i int, j int) float64 {
+	ri, rj := i, j
+	if m.Ranks != nil {
+		ri, rj = m.Ranks[i], m.Ranks[j]
+	}
+
+	switch {
+	case ri < rj:
+		return 1
+	case ri == rj:
+		return 0.5
+	default:
+		return 0
+	}
+}
+
+// RateMatch recalculates ratings of the players in the match. Players missing
+// in ratings start from CreateRating. It returns new ratings by player ID.
+func (s *TrueSkill) RateMatch(m *Match, ratings map[string]*Rating) (map[string]*Rating, error) {
+	return s.RateMatchWith(m, ratings)
+}
+
+// RateMatchWith is RateMatch with options such as Explain.
+func (s *TrueSkill) RateMatchWith(m *Match, ratings map[string]*Rating, opts ...rateOption) (map[string]*Rating, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	rated, err := s.rate(s.Groups(m, ratings), m.Constraints(), newRateConfig(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	return ByPlayer(m, rated), nil
+}
+
+// Groups returns ratings of the teams in the match. Players missing in
+// ratings get CreateRating.
+func (s *TrueSkill) Groups(m *Match, ratings map[string]*Rating) [][]*Rating {
+	return Groups(s, m, ratings)
+}
+
+// Groups returns ratings of the teams in the match. Players missing in
+// ratings get CreateRating of the rater.
+func Groups(r Rater, m *Match, ratings map[string]*Rating) [][]*Rating {
+	groups := make([][]*Rating, 0, len(m.Teams))
+	for _, team := range m.Teams {
+		group := make([]*Rating, 0, len(team))
+		for _, id := range team {
+			rating, ok := ratings[id]
+			if !ok {
+				rating = r.CreateRating()
+			}
+			group = append(group, rating)
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// ByPlayer maps ratings of the teams in the match to the player IDs.
+func ByPlayer(m *Match, groups [][]*Rating) map[string]*Rating {
+	result := make(map[string]*Rating)
+	for i, team := range m.Teams {
+		for j, id := range team {
+			result[id] = groups[i][j]
+		}
+	}
+	return result
+}
@@ -0,0 +1,59 @@
+package matchmaking
+
+import (
+	"sort"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/balance"
+)
+
+// Opponent is a potential opponent of a placement match.
+type Opponent struct {
+	Player         *balance.Player
+	Gain           float64 // the expected decrease of the sigma of the candidate.
+	WinProbability float64 // the probability that the candidate wins.
+}
+
+// RankOpponents ranks the pool by the expected decrease of the sigma of the
+// candidate after a 1v1 match, the largest first. The expectation is over
+// win, loss and draw weighted by their predicted probabilities, so opponents
+// with a certain rating near the candidate come first.
+func RankOpponents(env *trueskill.TrueSkill, candidate *trueskill.Rating, pool []*balance.Player) ([]*Opponent, error) {
+	outcomes := []trueskill.Constraint{
+		trueskill.Beat(0, 1),
+		trueskill.Beat(1, 0),
+		trueskill.Tie(0, 1),
+	}
+
+	opponents := make([]*Opponent, 0, len(pool))
+	for _, p := range pool {
+		a := []*trueskill.Rating{candidate}
+		b := []*trueskill.Rating{p.Rating}
+		probabilities := []float64{
+			env.WinProbability(a, b),
+			env.WinProbability(b, a),
+			env.TieProbability(a, b),
+		}
+
+		sigma := 0.0
+		for i, c := range outcomes {
+			rated, err := env.RatePartialOrder([][]*trueskill.Rating{a, b}, []trueskill.Constraint{c})
+			if err != nil {
+				return nil, err
+			}
+			sigma += probabilities[i] * rated[0][0].Sigma
+		}
+
+		opponents = append(opponents, &Opponent{
+			Player:         p,
+			Gain:           candidate.Sigma - sigma,
+			WinProbability: probabilities[0],
+		})
+	}
+
+	sort.SliceStable(opponents, func(i, j int) bool {
+		return opponents[i].Gain > opponents[j].Gain
+	})
+
+	return opponents, nil
+}
@@ -0,0 +1,34 @@
+package matchmaking_test
+
+import (
+	"testing"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/balance"
+	"github.com/gami/go-trueskill/matchmaking"
+)
+
+func TestRankOpponents(t *testing.T) {
+	env := trueskill.NewTrueSkill()
+	pool := []*balance.Player{
+		{ID: "far", Rating: trueskill.NewRating(60, 1, 1)},
+		{ID: "uncertain", Rating: trueskill.NewRating(25, 8, 1)},
+		{ID: "near", Rating: trueskill.NewRating(25, 1, 1)},
+	}
+
+	opponents, err := matchmaking.RankOpponents(env, env.CreateRating(), pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []string{}
+	for _, o := range opponents {
+		ids = append(ids, o.Player.ID)
+	}
+	if ids[0] != "near" || ids[2] != "far" {
+		t.Errorf("got %v", ids)
+	}
+	if opponents[2].Gain < 0 || opponents[0].Gain <= opponents[1].Gain {
+		t.Errorf("got %+v", opponents)
+	}
+}
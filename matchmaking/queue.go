@@ -0,0 +1,300 @@
+// Package matchmaking forms matches of the best quality from a queue of tickets.
+package matchmaking
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/balance"
+)
+
+// Ticket is a solo player or a party waiting for a match. Players of a
+// ticket are always in the same team.
+type Ticket struct {
+	ID         string
+	Players    []*balance.Player
+	EnqueuedAt time.Time // set by Enqueue if zero.
+}
+
+func (t *Ticket) mean() float64 {
+	sum := 0.0
+	for _, p := range t.Players {
+		sum += p.Rating.Mu
+	}
+	return sum / float64(len(t.Players))
+}
+
+// Match is a match formed from tickets.
+type Match struct {
+	Tickets   []*Ticket
+	Teams     [][]string // player IDs of each team.
+	Quality   float64
+	Threshold float64 // the threshold which the quality passed.
+	FormedAt  time.Time
+}
+
+// Queue holds tickets and forms matches on Tick.
+type Queue struct {
+	env      trueskill.Rater
+	clock    Clock
+	teams    int
+	teamSize int
+	start    float64
+	floor    float64
+	step     float64
+	every    time.Duration
+
+	mu      sync.Mutex
+	tickets []*Ticket
+	players map[string]string // ticket ID by player ID.
+}
+
+type option func(*Queue)
+
+func New(env trueskill.Rater, options ...option) *Queue {
+	q := &Queue{
+		env:      env,
+		clock:    SystemClock{},
+		teams:    2,
+		teamSize: 1,
+		start:    0.5,
+		floor:    0,
+		step:     0.05,
+		every:    10 * time.Second,
+		players:  make(map[string]string),
+	}
+
+	for _, opt := range options {
+		opt(q)
+	}
+
+	return q
+}
+
+// WithClock sets the clock. The default is SystemClock.
+func WithClock(c Clock) option {
+	return func(q *Queue) {
+		q.clock = c
+	}
+}
+
+// Teams is the number of teams in a match and the number of players in a
+// team. The default is 2 teams of 1 player.
+func Teams(teams int, size int) option {
+	return func(q *Queue) {
+		q.teams = teams
+		q.teamSize = size
+	}
+}
+
+// Threshold is the minimum quality of a match when tickets are just enqueued,
+// and the lowest it relaxes to. The default is 0.5 relaxing to 0.
+func Threshold(start float64, floor float64) option {
+	return func(q *Queue) {
+		q.start = start
+		q.floor = floor
+	}
+}
+
+// Relax lowers the threshold by step for each period of every which the
+// oldest ticket of a match has waited. The default is 0.05 per 10 seconds.
+func Relax(step float64, every time.Duration) option {
+	return func(q *Queue) {
+		q.step = step
+		q.every = every
+	}
+}
+
+// Enqueue adds a ticket to the queue.
+func (q *Queue) Enqueue(t *Ticket) error {
+	if len(t.Players) < 1 {
+		return errors.New("ticket must contain players")
+	}
+	if len(t.Players) > q.teamSize {
+		return fmt.Errorf("ticket %s has %d players, but a team has %d", t.ID, len(t.Players), q.teamSize)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, other := range q.tickets {
+		if other.ID == t.ID {
+			return fmt.Errorf("ticket %s is already queued", t.ID)
+		}
+	}
+	for _, p := range t.Players {
+		if id, ok := q.players[p.ID]; ok {
+			return fmt.Errorf("player %s is already queued in ticket %s", p.ID, id)
+		}
+	}
+
+	if t.EnqueuedAt.IsZero() {
+		t.EnqueuedAt = q.clock.Now()
+	}
+	for _, p := range t.Players {
+		q.players[p.ID] = t.ID
+	}
+	q.tickets = append(q.tickets, t)
+
+	return nil
+}
+
+// Cancel removes the ticket from the queue. It returns false if the ticket
+// is not queued.
+func (q *Queue) Cancel(id string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, t := range q.tickets {
+		if t.ID == id {
+			q.remove(i)
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of queued tickets.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.tickets)
+}
+
+// ThresholdAt returns the minimum quality for a match whose oldest ticket has
+// waited for wait.
+func (q *Queue) ThresholdAt(wait time.Duration) float64 {
+	if q.every <= 0 {
+		return q.start
+	}
+	relaxed := q.start - q.step*float64(wait)/float64(q.every)
+	return math.Max(q.floor, relaxed)
+}
+
+// Tick forms as many matches as possible at the current time and removes
+// their tickets from the queue. It is meant to be called periodically.
+//
+// Tickets are anchored from the oldest. Each anchor is grouped with the
+// tickets nearest to it by mean rating which fill the match, and the group
+// is balanced into teams. The match is formed if its quality passes the
+// threshold for the wait of the anchor.
+func (q *Queue) Tick() ([]*Match, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.clock.Now()
+	sort.SliceStable(q.tickets, func(i, j int) bool {
+		return q.tickets[i].EnqueuedAt.Before(q.tickets[j].EnqueuedAt)
+	})
+
+	matches := make([]*Match, 0)
+	for i := 0; i < len(q.tickets); {
+		m, err := q.form(i, now)
+		if err != nil {
+			return nil, err
+		}
+		if m == nil {
+			i++
+			continue
+		}
+
+		// Tickets before i which joined the match shift the rest back, so
+		// the next ticket to anchor is at i less their number.
+		for _, t := range m.Tickets {
+			for k, queued := range q.tickets {
+				if queued == t {
+					q.remove(k)
+					if k < i {
+						i--
+					}
+					break
+				}
+			}
+		}
+		matches = append(matches, m)
+	}
+
+	return matches, nil
+}
+
+// form tries a match anchored by the i-th ticket. Tickets before i have
+// failed as anchors, but they can still join.
+func (q *Queue) form(i int, now time.Time) (*Match, error) {
+	anchor := q.tickets[i]
+	need := q.teams * q.teamSize
+
+	others := make([]*Ticket, 0, len(q.tickets)-1)
+	for j, t := range q.tickets {
+		if j != i {
+			others = append(others, t)
+		}
+	}
+	mean := anchor.mean()
+	sort.SliceStable(others, func(a, b int) bool {
+		return math.Abs(others[a].mean()-mean) < math.Abs(others[b].mean()-mean)
+	})
+
+	picked := []*Ticket{anchor}
+	count := len(anchor.Players)
+	for _, t := range others {
+		if count == need {
+			break
+		}
+		if count+len(t.Players) <= need {
+			picked = append(picked, t)
+			count += len(t.Players)
+		}
+	}
+	if count < need {
+		return nil, nil
+	}
+
+	pool := make([]*balance.Player, 0, need)
+	parties := make([][]string, 0, len(picked))
+	for _, t := range picked {
+		party := make([]string, 0, len(t.Players))
+		for _, p := range t.Players {
+			pool = append(pool, p)
+			party = append(party, p.ID)
+		}
+		parties = append(parties, party)
+	}
+
+	sizes := make([]int, q.teams)
+	for t := range sizes {
+		sizes[t] = q.teamSize
+	}
+
+	r, err := balance.New(q.env, balance.Parties(parties...)).Balance(pool, sizes)
+	if errors.Is(err, balance.ErrNoFit) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := q.ThresholdAt(now.Sub(anchor.EnqueuedAt))
+	if r.Quality < threshold {
+		return nil, nil
+	}
+
+	return &Match{
+		Tickets:   picked,
+		Teams:     r.Teams,
+		Quality:   r.Quality,
+		Threshold: threshold,
+		FormedAt:  now,
+	}, nil
+}
+
+func (q *Queue) remove(i int) {
+	for _, p := range q.tickets[i].Players {
+		delete(q.players, p.ID)
+	}
+	q.tickets = append(q.tickets[:i], q.tickets[i+1:]...)
+}
@@ -0,0 +1,178 @@
+package matchmaking_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/balance"
+	"github.com/gami/go-trueskill/matchmaking"
+)
+
+func solo(id string, mu float64) *matchmaking.Ticket {
+	return &matchmaking.Ticket{
+		ID:      id,
+		Players: []*balance.Player{{ID: id, Rating: trueskill.NewRating(mu, 2, 1)}},
+	}
+}
+
+func TestQueueRelaxesThreshold(t *testing.T) {
+	clock := matchmaking.NewFakeClock(time.Unix(0, 0))
+	q := matchmaking.New(trueskill.NewTrueSkill(),
+		matchmaking.WithClock(clock),
+		matchmaking.Threshold(0.5, 0.05),
+		matchmaking.Relax(0.1, 10*time.Second),
+	)
+
+	for _, tk := range []*matchmaking.Ticket{solo("a", 20), solo("b", 20.5), solo("c", 40)} {
+		if err := q.Enqueue(tk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	matches, err := q.Tick()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Tickets[0].ID != "a" || matches[0].Tickets[1].ID != "b" {
+		t.Fatalf("a and b must match at once, got %+v", matches)
+	}
+
+	if err := q.Enqueue(solo("d", 25)); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 4; i++ {
+		clock.Advance(10 * time.Second)
+		matches, err := q.Tick()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(matches) != 0 {
+			t.Fatalf("c and d must not match after %d seconds, got quality %v", (i+1)*10, matches[0].Quality)
+		}
+	}
+
+	clock.Advance(time.Minute)
+	matches, err = q.Tick()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || q.Len() != 0 {
+		t.Fatalf("c and d must match when relaxed, got %+v", matches)
+	}
+	if matches[0].Quality < matches[0].Threshold || matches[0].Threshold >= 0.5 {
+		t.Errorf("got %+v", matches[0])
+	}
+}
+
+func TestQueueParties(t *testing.T) {
+	clock := matchmaking.NewFakeClock(time.Unix(0, 0))
+	q := matchmaking.New(trueskill.NewTrueSkill(),
+		matchmaking.WithClock(clock),
+		matchmaking.Teams(2, 2),
+		matchmaking.Threshold(0, 0),
+	)
+
+	party := &matchmaking.Ticket{
+		ID: "party",
+		Players: []*balance.Player{
+			{ID: "x", Rating: trueskill.NewRating(30, 2, 1)},
+			{ID: "y", Rating: trueskill.NewRating(30, 2, 1)},
+		},
+	}
+	for _, tk := range []*matchmaking.Ticket{party, solo("a", 30), solo("b", 30)} {
+		if err := q.Enqueue(tk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := q.Enqueue(solo("x", 30)); err == nil {
+		t.Error("player queued twice must be an error")
+	}
+
+	matches, err := q.Tick()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("got %d matches", len(matches))
+	}
+	teams := matches[0].Teams
+	if !(teams[0][0] == "x" && teams[0][1] == "y") && !(teams[1][0] == "x" && teams[1][1] == "y") {
+		t.Errorf("party is split: %v", teams)
+	}
+
+	if q.Cancel("party") {
+		t.Error("formed ticket must have left the queue")
+	}
+}
+
+func TestQueueAnchorsAfterEarlierJoin(t *testing.T) {
+	clock := matchmaking.NewFakeClock(time.Unix(0, 0))
+	q := matchmaking.New(trueskill.NewTrueSkill(),
+		matchmaking.WithClock(clock),
+		matchmaking.Threshold(0.97, 0),
+		matchmaking.Relax(0.1, time.Second),
+	)
+
+	for _, tk := range []*matchmaking.Ticket{solo("a", 20), solo("b", 22), solo("c", 40)} {
+		if err := q.Enqueue(tk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clock.Advance(5 * time.Second)
+
+	// a fails with the uncertain x, then b anchors a match with a. c must
+	// still anchor under its relaxed threshold, which e alone does not pass.
+	x := &matchmaking.Ticket{
+		ID:      "x",
+		Players: []*balance.Player{{ID: "x", Rating: trueskill.NewRating(19, 20, 1)}},
+	}
+	for _, tk := range []*matchmaking.Ticket{x, solo("e", 41)} {
+		if err := q.Enqueue(tk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	matches, err := q.Tick()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("got %d matches", len(matches))
+	}
+	if matches[1].Tickets[0].ID != "c" || matches[1].Tickets[1].ID != "e" {
+		t.Errorf("got %v %v", matches[1].Tickets[0].ID, matches[1].Tickets[1].ID)
+	}
+	if q.Len() != 1 {
+		t.Errorf("got %d tickets left", q.Len())
+	}
+}
+
+// failingRater fails to tell the quality of any match.
+type failingRater struct {
+	*trueskill.TrueSkill
+}
+
+func (failingRater) Quality(ratingGroups [][]*trueskill.Rating) (float64, error) {
+	return 0, errors.New("singular matrix")
+}
+
+func TestQueueReturnsRaterErrors(t *testing.T) {
+	q := matchmaking.New(failingRater{trueskill.NewTrueSkill()}, matchmaking.Threshold(0, 0))
+	for _, tk := range []*matchmaking.Ticket{solo("a", 20), solo("b", 20)} {
+		if err := q.Enqueue(tk); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := q.Tick(); err == nil || !strings.Contains(err.Error(), "singular matrix") {
+		t.Errorf("got %v", err)
+	}
+	if q.Len() != 2 {
+		t.Errorf("got %d tickets left", q.Len())
+	}
+}
@@ -0,0 +1,143 @@
+package mathmatics
+
+import (
+	"errors"
+	"math"
+)
+
+// Matrix is a dense matrix stored by rows.
+type Matrix [][]float64
+
+func NewMatrix(rows int, cols int) Matrix {
+	m := make(Matrix, rows)
+	for i := range m {
+		m[i] = make([]float64, cols)
+	}
+	return m
+}
+
+func (m Matrix) Rows() int {
+	return len(m)
+}
+
+func (m Matrix) Cols() int {
+	if len(m) == 0 {
+		return 0
+	}
+	return len(m[0])
+}
+
+func (m Matrix) Transpose() Matrix {
+	t := NewMatrix(m.Cols(), m.Rows())
+	for i, row := range m {
+		for j, v := range row {
+			t[j][i] = v
+		}
+	}
+	return t
+}
+
+func (m Matrix) Multiply(a Matrix) Matrix {
+	p := NewMatrix(m.Rows(), a.Cols())
+	for i := range p {
+		for j := range p[i] {
+			for k := 0; k < m.Cols(); k++ {
+				p[i][j] += m[i][k] * a[k][j]
+			}
+		}
+	}
+	return p
+}
+
+func (m Matrix) Add(a Matrix) Matrix {
+	s := NewMatrix(m.Rows(), m.Cols())
+	for i := range s {
+		for j := range s[i] {
+			s[i][j] = m[i][j] + a[i][j]
+		}
+	}
+	return s
+}
+
+func (m Matrix) Scale(c float64) Matrix {
+	s := NewMatrix(m.Rows(), m.Cols())
+	for i := range s {
+		for j := range s[i] {
+			s[i][j] = c * m[i][j]
+		}
+	}
+	return s
+}
+
+// Determinant calculates the determinant of a square matrix by LU decomposition.
+func (m Matrix) Determinant() float64 {
+	n := m.Rows()
+	lu := m.Scale(1)
+	det := 1.0
+	for c := 0; c < n; c++ {
+		pivot := c
+		for r := c + 1; r < n; r++ {
+			if math.Abs(lu[r][c]) > math.Abs(lu[pivot][c]) {
+				pivot = r
+			}
+		}
+		if lu[pivot][c] == 0 {
+			return 0
+		}
+		if pivot != c {
+			lu[pivot], lu[c] = lu[c], lu[pivot]
+			det = -det
+		}
+		det *= lu[c][c]
+		for r := c + 1; r < n; r++ {
+			f := lu[r][c] / lu[c][c]
+			for k := c; k < n; k++ {
+				lu[r][k] -= f * lu[c][k]
+			}
+		}
+	}
+	return det
+}
+
+// Inverse calculates the inverse of a square matrix by Gauss-Jordan elimination.
+func (m Matrix) Inverse() (Matrix, error) {
+	n := m.Rows()
+	a := m.Scale(1)
+	inv := NewMatrix(n, n)
+	for i := range inv {
+		inv[i][i] = 1
+	}
+
+	for c := 0; c < n; c++ {
+		pivot := c
+		for r := c + 1; r < n; r++ {
+			if math.Abs(a[r][c]) > math.Abs(a[pivot][c]) {
+				pivot = r
+			}
+		}
+		if a[pivot][c] == 0 {
+			return nil, errors.New("matrix is singular")
+		}
+		a[pivot], a[c] = a[c], a[pivot]
+		inv[pivot], inv[c] = inv[c], inv[pivot]
+
+		f := a[c][c]
+		for k := 0; k < n; k++ {
+			a[c][k] /= f
+			inv[c][k] /= f
+		}
+
+		for r := 0; r < n; r++ {
+			if r == c {
+				continue
+			}
+			f := a[r][c]
+			for k := 0; k < n; k++ {
+				a[r][k] -= f * a[c][k]
+				inv[r][k] -= f * inv[c][k]
+			}
+		}
+	}
+
+	return inv, nil
+}
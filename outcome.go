@@ -0,0 +1,158 @@
+package trueskill
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Constraint is an observed outcome between two groups given by index.
+type Constraint struct {
+	Winner int
+	Loser  int
+	Draw   bool // the groups are tied. Winner and Loser are interchangeable.
+}
+
+// Beat is a constraint that the winner beat the loser.
+func Beat(winner int, loser int) Constraint {
+	return Constraint{Winner: winner, Loser: loser}
+}
+
+// Tie is a constraint that the two groups are tied.
+func Tie(a int, b int) Constraint {
+	return Constraint{Winner: a, Loser: b, Draw: true}
+}
+
+// WinnerOnly makes constraints of a match among size groups where only the
+// winner is known.
+func WinnerOnly(winner int, size int) []Constraint {
+	constraints := make([]Constraint, 0, size-1)
+	for i := 0; i < size; i++ {
+		if i != winner {
+			constraints = append(constraints, Beat(winner, i))
+		}
+	}
+	return constraints
+}
+
+// chainConstraints makes the outcomes of groups sorted by rank.
+func chainConstraints(size int) []Constraint {
+	constraints := make([]Constraint, 0, size-1)
+	for i := 0; i < size-1; i++ {
+		constraints = append(constraints, Constraint{Winner: i, Loser: i + 1})
+	}
+	return constraints
+}
+
+// RateTopK recalculates ratings of a match where only the first k groups are
+// sorted by rank. The remaining groups were beaten by the k-th group, but
+// the order among them is unknown.
+func (s *TrueSkill) RateTopK(ratingGroups [][]*Rating, k int, opts ...rateOption) ([][]*Rating, error) {
+	if err := s.validateRatingGroup(ratingGroups); err != nil {
+		return nil, err
+	}
+
+	if k < 1 || k > len(ratingGroups) {
+		return nil, fmt.Errorf("k must be between 1 and %d, got %d", len(ratingGroups), k)
+	}
+
+	constraints := chainConstraints(k)
+	for i := k; i < len(ratingGroups); i++ {
+		constraints = append(constraints, Constraint{Winner: k - 1, Loser: i})
+	}
+
+	return s.rate(ratingGroups, constraints, newRateConfig(opts))
+}
+
+// RatePartialOrder recalculates ratings by pairwise outcomes between the
+// groups. The outcomes may leave the order of some groups unknown, but every
+// group must appear in some constraint and wins must not form a cycle.
+func (s *TrueSkill) RatePartialOrder(ratingGroups [][]*Rating, constraints []Constraint, opts ...rateOption) ([][]*Rating, error) {
+	if err := s.validateRatingGroup(ratingGroups); err != nil {
+		return nil, err
+	}
+
+	if err := validateConstraints(len(ratingGroups), constraints); err != nil {
+		return nil, err
+	}
+
+	return s.rate(ratingGroups, constraints, newRateConfig(opts))
+}
+
+func validateConstraints(size int, constraints []Constraint) error {
+	if len(constraints) == 0 {
+		return errors.New("need at least one constraint")
+	}
+
+	compared := make([]bool, size)
+
+	// Tied groups are merged so that wins among them are found as cycles.
+	tied := make([]int, size)
+	for i := range tied {
+		tied[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if tied[i] != i {
+			tied[i] = find(tied[i])
+		}
+		return tied[i]
+	}
+
+	for _, c := range constraints {
+		if c.Winner < 0 || c.Winner >= size || c.Loser < 0 || c.Loser >= size {
+			return fmt.Errorf("constraint %d-%d is out of %d groups", c.Winner, c.Loser, size)
+		}
+		if c.Winner == c.Loser {
+			return fmt.Errorf("constraint %d-%d compares a group with itself", c.Winner, c.Loser)
+		}
+		compared[c.Winner] = true
+		compared[c.Loser] = true
+
+		if c.Draw {
+			tied[find(c.Winner)] = find(c.Loser)
+		}
+	}
+
+	for i, ok := range compared {
+		if !ok {
+			return fmt.Errorf("group %d is not in any constraint", i)
+		}
+	}
+
+	beats := make(map[int][]int)
+	for _, c := range constraints {
+		if !c.Draw {
+			w, l := find(c.Winner), find(c.Loser)
+			if w == l {
+				return fmt.Errorf("constraint %d-%d contradicts a tie", c.Winner, c.Loser)
+			}
+			beats[w] = append(beats[w], l)
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, size)
+	var visit func(i int) bool
+	visit = func(i int) bool {
+		state[i] = visiting
+		for _, l := range beats[i] {
+			if state[l] == visiting || (state[l] == unvisited && !visit(l)) {
+				return false
+			}
+		}
+		state[i] = visited
+		return true
+	}
+
+	for i := 0; i < size; i++ {
+		if state[i] == unvisited && !visit(i) {
+			return errors.New("constraints must not contain a cycle of wins")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package trueskill_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gami/go-trueskill"
+)
+
+func newGroups(ts *trueskill.TrueSkill, sizes ...int) [][]*trueskill.Rating {
+	groups := make([][]*trueskill.Rating, 0, len(sizes))
+	for _, size := range sizes {
+		g := make([]*trueskill.Rating, 0, size)
+		for i := 0; i < size; i++ {
+			g = append(g, ts.CreateRating())
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+func TestRateTopK(t *testing.T) {
+	ts := trueskill.NewTrueSkill()
+
+	got, err := ts.RateTopK(newGroups(ts, 1, 1, 1, 1, 1, 1), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i < 3; i++ {
+		if got[i][0].Mu >= got[i-1][0].Mu {
+			t.Errorf("rank=%d mu=%v must be below rank=%d mu=%v", i, got[i][0].Mu, i-1, got[i-1][0].Mu)
+		}
+	}
+
+	for i := 3; i < len(got); i++ {
+		if got[i][0].Mu >= got[2][0].Mu {
+			t.Errorf("eliminated group=%d mu=%v must be below the k-th mu=%v", i, got[i][0].Mu, got[2][0].Mu)
+		}
+		if math.Abs(got[i][0].Mu-got[3][0].Mu) > trueskill.MinDelta {
+			t.Errorf("eliminated group=%d mu=%v must equal to group=3 mu=%v", i, got[i][0].Mu, got[3][0].Mu)
+		}
+	}
+}
+
+func TestRateTopKFullRanking(t *testing.T) {
+	ts := trueskill.NewTrueSkill()
+
+	want, err := ts.Rate(newGroups(ts, 2, 1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ts.RateTopK(newGroups(ts, 2, 1, 1), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range want {
+		for j := range want[i] {
+			if *got[i][j] != *want[i][j] {
+				t.Errorf("group=%d member=%d got=%+v want=%+v", i, j, got[i][j], want[i][j])
+			}
+		}
+	}
+
+	if _, err := ts.RateTopK(newGroups(ts, 1, 1), 0); err == nil {
+		t.Error("k=0 must be an error")
+	}
+}
+
+func TestRatePartialOrder(t *testing.T) {
+	ts := trueskill.NewTrueSkill()
+
+	// A beat B and C, B vs C unknown, A tied with D.
+	got, err := ts.RatePartialOrder(newGroups(ts, 1, 1, 1, 1), []trueskill.Constraint{
+		trueskill.Beat(0, 1),
+		trueskill.Beat(0, 2),
+		trueskill.Tie(0, 3),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(got[1][0].Mu-got[2][0].Mu) > trueskill.MinDelta {
+		t.Errorf("B mu=%v and C mu=%v must be equal", got[1][0].Mu, got[2][0].Mu)
+	}
+	if got[0][0].Mu <= got[1][0].Mu || got[3][0].Mu <= got[1][0].Mu {
+		t.Errorf("A mu=%v and D mu=%v must be above B mu=%v", got[0][0].Mu, got[3][0].Mu, got[1][0].Mu)
+	}
+
+	want, err := ts.RateTopK(newGroups(ts, 1, 1, 1), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = ts.RatePartialOrder(newGroups(ts, 1, 1, 1), trueskill.WinnerOnly(0, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range want {
+		if *got[i][0] != *want[i][0] {
+			t.Errorf("group=%d got=%+v want=%+v", i, got[i][0], want[i][0])
+		}
+	}
+}
+
+func TestRatePartialOrderInvalid(t *testing.T) {
+	ts := trueskill.NewTrueSkill()
+
+	tests := []struct {
+		name        string
+		constraints []trueskill.Constraint
+	}{
+		{"empty", nil},
+		{"out of range", []trueskill.Constraint{trueskill.Beat(0, 3)}},
+		{"self", []trueskill.Constraint{trueskill.Beat(0, 0), trueskill.Beat(1, 2)}},
+		{"uncompared", []trueskill.Constraint{trueskill.Beat(0, 1)}},
+		{"cycle", []trueskill.Constraint{trueskill.Beat(0, 1), trueskill.Beat(1, 2), trueskill.Beat(2, 0)}},
+		{"win within a tie", []trueskill.Constraint{trueskill.Tie(0, 1), trueskill.Tie(1, 2), trueskill.Beat(2, 0)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ts.RatePartialOrder(newGroups(ts, 1, 1, 1), tt.constraints); err == nil {
+				t.Error("must be an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package trueskill
+
+import (
+	"math"
+
+	"github.com/chobie/go-gaussian"
+	"github.com/gami/go-trueskill/mathmatics"
+)
+
+// teamPerformance returns the mean and the variance of a team performance.
+func (s *TrueSkill) teamPerformance(team []*Rating) (float64, float64) {
+	mu := 0.0
+	variance := 0.0
+	for _, r := range team {
+		mu += r.Weight * r.Mu
+		variance += math.Pow(r.Weight, 2) * (math.Pow(r.Sigma, 2) + math.Pow(s.beta, 2))
+	}
+	return mu, variance
+}
+
+// diff returns the standardized performance difference of a over b and the
+// standardized draw margin.
+func (s *TrueSkill) diff(a []*Rating, b []*Rating) (float64, float64) {
+	muA, varA := s.teamPerformance(a)
+	muB, varB := s.teamPerformance(b)
+	c := math.Sqrt(varA + varB)
+	return (muA - muB) / c, s.calcDrawMargin(len(a)+len(b)) / c
+}
+
+// WinProbability returns the probability that team a beats team b.
+func (s *TrueSkill) WinProbability(a []*Rating, b []*Rating) float64 {
+	d, margin := s.diff(a, b)
+	g := gaussian.NewGaussian(0.0, 1.0)
+	return g.Cdf(d - margin)
+}
+
+// TieProbability returns the probability that team a and team b are tied.
+func (s *TrueSkill) TieProbability(a []*Rating, b []*Rating) float64 {
+	d, margin := s.diff(a, b)
+	g := gaussian.NewGaussian(0.0, 1.0)
+	return g.Cdf(margin-d) - g.Cdf(-margin-d)
+}
+
+// Likelihood returns the probability of the outcome before rating. The
+// constraints are treated as independent, so it is an approximation when
+// there are more than two groups.
+func (s *TrueSkill) Likelihood(ratingGroups [][]*Rating, constraints []Constraint) float64 {
+	p := 1.0
+	for _, c := range constraints {
+		if c.Draw {
+			p *= s.TieProbability(ratingGroups[c.Winner], ratingGroups[c.Loser])
+		} else {
+			p *= s.WinProbability(ratingGroups[c.Winner], ratingGroups[c.Loser])
+		}
+	}
+	return p
+}
+
+// Quality returns the draw probability of the match relative to the draw
+// probability of a match between equal teams. It is between 0 and 1, and
+// higher means a fairer match.
+func (s *TrueSkill) Quality(ratingGroups [][]*Rating) (float64, error) {
+	if err := s.validateRatingGroup(ratingGroups); err != nil {
+		return 0, err
+	}
+
+	flattenRatings := make([]*Rating, 0)
+	for _, rg := range ratingGroups {
+		flattenRatings = append(flattenRatings, rg...)
+	}
+	size := len(flattenRatings)
+
+	mean := mathmatics.NewMatrix(size, 1)
+	variance := mathmatics.NewMatrix(size, size)
+	for i, r := range flattenRatings {
+		mean[i][0] = r.Mu
+		variance[i][i] = math.Pow(r.Sigma, 2)
+	}
+
+	// Each row compares a team with the next one.
+	rotated := mathmatics.NewMatrix(len(ratingGroups)-1, size)
+	index := 0
+	for i := 0; i < len(ratingGroups)-1; i++ {
+		for _, r := range ratingGroups[i] {
+			rotated[i][index] = r.Weight
+			index++
+		}
+		next := index
+		for _, r := range ratingGroups[i+1] {
+			rotated[i][next] = -r.Weight
+			next++
+		}
+	}
+	a := rotated.Transpose()
+
+	ata := rotated.Multiply(a).Scale(math.Pow(s.beta, 2))
+	atsa := rotated.Multiply(variance).Multiply(a)
+	start := mean.Transpose().Multiply(a)
+	middle := ata.Add(atsa)
+	end := rotated.Multiply(mean)
+
+	inv, err := middle.Inverse()
+	if err != nil {
+		return 0, err
+	}
+
+	eArg := start.Multiply(inv).Multiply(end).Scale(-0.5).Determinant()
+	sArg := ata.Determinant() / middle.Determinant()
+
+	return math.Exp(eArg) * math.Sqrt(sArg), nil
+}
@@ -0,0 +1,90 @@
+package trueskill
+
+// Preview is the change of the exposure of a player by each outcome of a
+// match which has not been played yet.
+type Preview struct {
+	Win  float64 `json:"win"`  // the team of the player beats every other team.
+	Lose float64 `json:"lose"` // every other team beats the team of the player.
+	Draw float64 `json:"draw"` // all teams are tied.
+}
+
+// Preview rates the groups hypothetically by each outcome and returns the
+// exposure deltas of each player. The layers down to the team performances
+// are built once and shared by the outcomes.
+func (s *TrueSkill) Preview(ratingGroups [][]*Rating) ([][]*Preview, error) {
+	if err := s.validateRatingGroup(ratingGroups); err != nil {
+		return nil, err
+	}
+	if err := s.validateOptions(); err != nil {
+		return nil, err
+	}
+
+	// Options such as Explain would describe only one of the outcomes.
+	cfg := newRateConfig(nil)
+	g := s.buildRateGraph(ratingGroups)
+	states := g.save()
+
+	solve := func(constraints []Constraint) ([][]*Rating, error) {
+		g.restore(states)
+		if err := s.runSchedule(g, constraints, cfg); err != nil {
+			return nil, err
+		}
+		return g.ratings(), nil
+	}
+
+	n := len(ratingGroups)
+
+	wins := make([][][]*Rating, 0, n)
+	for i := 0; i < n; i++ {
+		rated, err := solve(WinnerOnly(i, n))
+		if err != nil {
+			return nil, err
+		}
+		wins = append(wins, rated)
+	}
+
+	// With two teams, a team loses when the other team wins.
+	losses := make([][][]*Rating, 0, n)
+	for i := 0; i < n; i++ {
+		if n == 2 {
+			losses = append(losses, wins[1-i])
+			continue
+		}
+
+		constraints := make([]Constraint, 0, n-1)
+		for j := 0; j < n; j++ {
+			if j != i {
+				constraints = append(constraints, Beat(j, i))
+			}
+		}
+		rated, err := solve(constraints)
+		if err != nil {
+			return nil, err
+		}
+		losses = append(losses, rated)
+	}
+
+	ties := make([]Constraint, 0, n-1)
+	for i := 0; i < n-1; i++ {
+		ties = append(ties, Tie(i, i+1))
+	}
+	draws, err := solve(ties)
+	if err != nil {
+		return nil, err
+	}
+
+	previews := make([][]*Preview, 0, n)
+	for i, group := range ratingGroups {
+		previews = append(previews, make([]*Preview, 0, len(group)))
+		for j, r := range group {
+			before := s.Expose(r)
+			previews[i] = append(previews[i], &Preview{
+				Win:  s.Expose(wins[i][i][j]) - before,
+				Lose: s.Expose(losses[i][i][j]) - before,
+				Draw: s.Expose(draws[i][j]) - before,
+			})
+		}
+	}
+
+	return previews, nil
+}
@@ -0,0 +1,87 @@
+package trueskill_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gami/go-trueskill"
+)
+
+func TestPreview(t *testing.T) {
+	ts := trueskill.NewTrueSkill()
+	groups := [][]*trueskill.Rating{
+		{trueskill.NewRating(30, 4, 1), trueskill.NewRating(20, 6, 1)},
+		{trueskill.NewRating(25, 5, 1), trueskill.NewRating(22, 3, 1)},
+		{trueskill.NewRating(28, 7, 1), trueskill.NewRating(18, 2, 1)},
+	}
+
+	previews, err := ts.Preview(groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range groups {
+		others := make([]int, 0)
+		for j := range groups {
+			if j != i {
+				others = append(others, j)
+			}
+		}
+
+		won, err := ts.RatePartialOrder(groups, trueskill.WinnerOnly(i, len(groups)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		lost, err := ts.RatePartialOrder(groups, []trueskill.Constraint{
+			trueskill.Beat(others[0], i),
+			trueskill.Beat(others[1], i),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		drawn, err := ts.RatePartialOrder(groups, []trueskill.Constraint{trueskill.Tie(0, 1), trueskill.Tie(1, 2)})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for j, r := range groups[i] {
+			p := previews[i][j]
+			before := ts.Expose(r)
+			for _, c := range []struct {
+				name string
+				got  float64
+				want float64
+			}{
+				{"win", p.Win, ts.Expose(won[i][j]) - before},
+				{"lose", p.Lose, ts.Expose(lost[i][j]) - before},
+				{"draw", p.Draw, ts.Expose(drawn[i][j]) - before},
+			} {
+				if math.Abs(c.got-c.want) > 1e-9 {
+					t.Errorf("team %d player %d %s: got %v, want %v", i, j, c.name, c.got, c.want)
+				}
+			}
+			if p.Win <= p.Draw || p.Draw <= p.Lose {
+				t.Errorf("team %d player %d: got %+v", i, j, p)
+			}
+		}
+	}
+}
+
+func TestPreview1v1(t *testing.T) {
+	ts := trueskill.NewTrueSkill()
+	a, b := ts.CreateRating(), ts.CreateRating()
+
+	previews, err := ts.Preview([][]*trueskill.Rating{{a}, {b}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rated, err := ts.Rate1v1(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(previews[0][0].Win-(ts.Expose(rated[0])-ts.Expose(a))) > 1e-9 ||
+		math.Abs(previews[1][0].Lose-(ts.Expose(rated[1])-ts.Expose(b))) > 1e-9 {
+		t.Errorf("got %+v %+v", previews[0][0], previews[1][0])
+	}
+}
@@ -0,0 +1,18 @@
+package trueskill
+
+// Rater is a rating system which rates matches of players identified by IDs.
+// TrueSkill implements it, and the elo and glicko2 packages give others.
+type Rater interface {
+	// CreateRating returns the rating of a new player.
+	CreateRating() *Rating
+	// RateMatch returns new ratings of the players in the match by player ID.
+	RateMatch(m *Match, ratings map[string]*Rating) (map[string]*Rating, error)
+	// WinProbability returns the probability that team a beats team b.
+	WinProbability(a []*Rating, b []*Rating) float64
+	// Quality returns how even the match is, between 0 and 1.
+	Quality(ratingGroups [][]*Rating) (float64, error)
+	// Expose returns the value of the rating shown to players.
+	Expose(r *Rating) float64
+}
+
+var _ Rater = (*TrueSkill)(nil)
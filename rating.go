@@ -7,6 +7,11 @@ type Rating struct {
 	Mu     float64 // the mean.
 	Sigma  float64 // the square root of the variance.
 	Weight float64 // default 1
+
+	// Volatility is the expected fluctuation of the rating. Only Glicko-2
+	// uses it, and carries it in and out of RateMatch with the rest of the
+	// rating. Other raters leave it 0.
+	Volatility float64
 }
 
 type ratingOpt func(*Rating)
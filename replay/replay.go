@@ -0,0 +1,181 @@
+// Package replay rates a history of matches from scratch.
+package replay
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/gami/go-trueskill"
+)
+
+// Source is an ordered stream of matches.
+type Source interface {
+	// Next returns the next match, or io.EOF after the last one.
+	Next() (*trueskill.Match, error)
+}
+
+type sliceSource struct {
+	matches []*trueskill.Match
+	pos     int
+}
+
+// FromSlice makes a source of the matches in order.
+func FromSlice(matches []*trueskill.Match) Source {
+	return &sliceSource{matches: matches}
+}
+
+func (s *sliceSource) Next() (*trueskill.Match, error) {
+	if s.pos >= len(s.matches) {
+		return nil, io.EOF
+	}
+	m := s.matches[s.pos]
+	s.pos++
+	return m, nil
+}
+
+// Checkpoint is the state of a replay after some matches.
+type Checkpoint struct {
+	Processed   int                         `json:"processed"`     // the number of matches rated.
+	LastMatchID string                      `json:"last_match_id"` // the ID of the last match rated.
+	Ratings     map[string]trueskill.Rating `json:"ratings"`
+}
+
+// Players returns IDs of the rated players in order.
+func (c *Checkpoint) Players() []string {
+	ids := make([]string, 0, len(c.Ratings))
+	for id := range c.Ratings {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Replayer applies Rate to matches sequentially.
+type Replayer struct {
+	env          trueskill.Rater
+	interval     int
+	onCheckpoint func(*Checkpoint) error
+	beforeMatch  func(m *trueskill.Match, ratings map[string]*trueskill.Rating) error
+
+	ratings   map[string]*trueskill.Rating
+	processed int
+	lastID    string
+}
+
+type option func(*Replayer)
+
+func New(env trueskill.Rater, options ...option) *Replayer {
+	r := &Replayer{
+		env:     env,
+		ratings: make(map[string]*trueskill.Rating),
+	}
+
+	for _, opt := range options {
+		opt(r)
+	}
+
+	return r
+}
+
+// Interval makes a checkpoint every n matches. 0 disables checkpoints.
+func Interval(n int) option {
+	return func(r *Replayer) {
+		r.interval = n
+	}
+}
+
+// OnCheckpoint calls fn with each checkpoint. An error of fn stops the replay.
+func OnCheckpoint(fn func(*Checkpoint) error) option {
+	return func(r *Replayer) {
+		r.onCheckpoint = fn
+	}
+}
+
+// BeforeMatch calls fn with each match and the ratings before it is rated.
+// fn must not modify the ratings. An error of fn stops the replay.
+func BeforeMatch(fn func(m *trueskill.Match, ratings map[string]*trueskill.Rating) error) option {
+	return func(r *Replayer) {
+		r.beforeMatch = fn
+	}
+}
+
+// Resume restores the state of the checkpoint. The following Run skips the
+// matches which the checkpoint has already processed.
+func (r *Replayer) Resume(cp *Checkpoint) {
+	r.ratings = make(map[string]*trueskill.Rating, len(cp.Ratings))
+	for id, rating := range cp.Ratings {
+		rating := rating
+		r.ratings[id] = &rating
+	}
+	r.processed = cp.Processed
+	r.lastID = cp.LastMatchID
+}
+
+// Run rates every match of the source from the beginning and returns the
+// final state. When the replayer is resumed, the source must yield the same
+// matches as the replay which made the checkpoint.
+func (r *Replayer) Run(src Source) (*Checkpoint, error) {
+	for index := 0; ; index++ {
+		m, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if index < r.processed {
+			if index == r.processed-1 && m.ID != r.lastID {
+				return nil, fmt.Errorf("match %d is %q, but the checkpoint ends at %q", index, m.ID, r.lastID)
+			}
+			continue
+		}
+
+		if err := r.apply(m); err != nil {
+			return nil, err
+		}
+
+		if r.interval > 0 && r.processed%r.interval == 0 && r.onCheckpoint != nil {
+			if err := r.onCheckpoint(r.Checkpoint()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return r.Checkpoint(), nil
+}
+
+func (r *Replayer) apply(m *trueskill.Match) error {
+	if r.beforeMatch != nil {
+		if err := r.beforeMatch(m, r.ratings); err != nil {
+			return err
+		}
+	}
+
+	rated, err := r.env.RateMatch(m, r.ratings)
+	if err != nil {
+		return fmt.Errorf("match %q: %w", m.ID, err)
+	}
+
+	for id, rating := range rated {
+		r.ratings[id] = rating
+	}
+	r.processed++
+	r.lastID = m.ID
+
+	return nil
+}
+
+// Checkpoint returns the current state.
+func (r *Replayer) Checkpoint() *Checkpoint {
+	cp := &Checkpoint{
+		Processed:   r.processed,
+		LastMatchID: r.lastID,
+		Ratings:     make(map[string]trueskill.Rating, len(r.ratings)),
+	}
+	for id, rating := range r.ratings {
+		cp.Ratings[id] = *rating
+	}
+	return cp
+}
@@ -0,0 +1,102 @@
+package replay_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/glicko2"
+	"github.com/gami/go-trueskill/replay"
+)
+
+func history() []*trueskill.Match {
+	players := []string{"a", "b", "c", "d"}
+	matches := make([]*trueskill.Match, 0)
+	for i := 0; i < 10; i++ {
+		matches = append(matches, &trueskill.Match{
+			ID:    fmt.Sprintf("m%d", i),
+			Time:  time.Unix(int64(i), 0),
+			Teams: [][]string{{players[i%4]}, {players[(i+1)%4]}, {players[(i+2)%4]}},
+			Ranks: []int{0, 1, 1},
+		})
+	}
+	return matches
+}
+
+func TestReplay(t *testing.T) {
+	ts := trueskill.NewTrueSkill()
+
+	want := make(map[string]*trueskill.Rating)
+	for _, m := range history() {
+		rated, err := ts.RateMatch(m, want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for id, r := range rated {
+			want[id] = r
+		}
+	}
+
+	checkpoints := make([]*replay.Checkpoint, 0)
+	r := replay.New(ts,
+		replay.Interval(4),
+		replay.OnCheckpoint(func(cp *replay.Checkpoint) error {
+			checkpoints = append(checkpoints, cp)
+			return nil
+		}),
+	)
+
+	got, err := r.Run(replay.FromSlice(history()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Processed != 10 || got.LastMatchID != "m9" {
+		t.Errorf("got processed=%d last=%s", got.Processed, got.LastMatchID)
+	}
+	for id, rating := range want {
+		if got.Ratings[id] != *rating {
+			t.Errorf("player=%s got=%+v want=%+v", id, got.Ratings[id], *rating)
+		}
+	}
+	if len(checkpoints) != 2 || checkpoints[1].Processed != 8 {
+		t.Fatalf("got %d checkpoints", len(checkpoints))
+	}
+
+	resumed := replay.New(ts)
+	resumed.Resume(checkpoints[0])
+	rest, err := resumed.Run(replay.FromSlice(history()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rest, got) {
+		t.Errorf("resumed replay differs: got=%+v want=%+v", rest, got)
+	}
+
+	other := replay.New(ts)
+	other.Resume(checkpoints[0])
+	if _, err := other.Run(replay.FromSlice(history()[1:])); err == nil {
+		t.Error("a source different from the checkpoint must be an error")
+	}
+}
+
+func TestReplayIsDeterministic(t *testing.T) {
+	env := glicko2.New()
+
+	first, err := replay.New(env).Run(replay.FromSlice(history()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := replay.New(env).Run(replay.FromSlice(history()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("got %+v, then %+v", first, second)
+	}
+	if first.Ratings["a"].Volatility == 0 {
+		t.Errorf("volatility is not carried: %+v", first.Ratings["a"])
+	}
+}
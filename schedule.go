@@ -0,0 +1,259 @@
+package trueskill
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gami/go-trueskill/factorgraph"
+)
+
+// Schedule is an order of message passing within the team difference layer.
+type Schedule int
+
+const (
+	// SweepSchedule passes messages forward and backward along the teams.
+	SweepSchedule Schedule = iota
+	// ResidualSchedule always updates the team difference whose last
+	// update changed its neighbors the most.
+	ResidualSchedule
+)
+
+// maxIterations is the limit of sweeps over the team difference layer.
+const maxIterations = 10
+
+// iterationLimit is the number of sweeps after the first. It extends
+// maxIterations by the damping, because damped messages take more iterations
+// to converge. The tolerance keeps rounding from adding a sweep, as
+// 10 / (1 - 0.9) is 100.00000000000001.
+func (s *TrueSkill) iterationLimit() int {
+	return int(math.Ceil(maxIterations/(1-s.damping) - 1e-9))
+}
+
+// validateOptions checks the options which NewTrueSkill cannot reject.
+func (s *TrueSkill) validateOptions() error {
+	if s.damping < 0 || s.damping >= 1 {
+		return fmt.Errorf("damping must be in [0, 1), got %v", s.damping)
+	}
+	switch s.schedule {
+	case SweepSchedule, ResidualSchedule:
+		return nil
+	default:
+		return fmt.Errorf("unknown schedule %d", s.schedule)
+	}
+}
+
+// passMessages sends messages from the team difference layer up to the
+// ratings, after the messages down to the team performances are sent. It
+// returns the number of iterations and whether they converged before the
+// limit.
+func (s *TrueSkill) passMessages(
+	perfLayer []factorgraph.Factor,
+	teamPerfLayer []*factorgraph.SumFactor,
+	teamDiffLayer []*factorgraph.SumFactor,
+	truncLayer []*factorgraph.TruncateFactor,
+	constraints []Constraint,
+	inspect func(iteration int),
+) (int, bool, error) {
+	teamDiffLen := len(teamDiffLayer)
+
+	for _, f := range truncLayer {
+		f.SetDamping(s.damping)
+	}
+
+	var iterations int
+	var converged bool
+	var err error
+	switch s.schedule {
+	case ResidualSchedule:
+		iterations, converged, err = s.runResidualSchedule(teamDiffLayer, truncLayer, constraints, inspect)
+	default:
+		iterations, converged, err = s.runSweepSchedule(teamDiffLayer, truncLayer, constraints, inspect)
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	// Up both ends
+	teamDiffLayer[0].SetPointer(0)
+	teamDiffLayer[0].Up()
+	teamDiffLayer[teamDiffLen-1].SetPointer(1)
+	teamDiffLayer[teamDiffLen-1].Up()
+
+	// Up the remainder of the black arrows
+	for _, f := range teamPerfLayer {
+		f.SetPointer(0)
+		for x := 0; x < len(f.Vars)-1; x++ {
+			f.Up()
+		}
+	}
+
+	for _, f := range perfLayer {
+		f.Up()
+	}
+
+	return iterations, converged, nil
+}
+
+// runSweepSchedule runs forward and backward sweeps over the team difference
+// layer. When the groups are a chain sorted by rank, a forward sweep sends
+// messages to the losers and a backward sweep sends them to the winners.
+// Otherwise a team performance can be shared by any team differences, so
+// each update sends messages to both teams.
+func (s *TrueSkill) runSweepSchedule(
+	teamDiffLayer []*factorgraph.SumFactor,
+	truncLayer []*factorgraph.TruncateFactor,
+	constraints []Constraint,
+	inspect func(iteration int),
+) (int, bool, error) {
+	teamDiffLen := len(teamDiffLayer)
+
+	limit := s.iterationLimit()
+
+	iterations := 0
+	for index := 0; index <= limit; index++ {
+		iterations++
+		delta := 0.0
+		var err error
+		if teamDiffLen == 1 {
+			// Only two teams
+			teamDiffLayer[0].Down()
+			delta, err = truncLayer[0].Up()
+			if err != nil {
+				return 0, false, err
+			}
+		} else if !isChain(constraints) {
+			// Partial order
+			update := func(z int) error {
+				teamDiffLayer[z].Down()
+				d, err := truncLayer[z].Up()
+				if err != nil {
+					return err
+				}
+				delta = math.Max(delta, d)
+				teamDiffLayer[z].SetPointer(0)
+				teamDiffLayer[z].Up()
+				teamDiffLayer[z].Up()
+				return nil
+			}
+
+			for z := 0; z < teamDiffLen; z++ {
+				if err := update(z); err != nil {
+					return 0, false, err
+				}
+			}
+
+			for z := teamDiffLen - 1; z >= 0; z-- {
+				if err := update(z); err != nil {
+					return 0, false, err
+				}
+			}
+		} else {
+			// Multiple teams
+			for z := 0; z < teamDiffLen-1; z++ {
+				teamDiffLayer[z].Down()
+				d, err := truncLayer[z].Up()
+				if err != nil {
+					return 0, false, err
+				}
+				delta = math.Max(delta, d)
+				teamDiffLayer[z].SetPointer(1)
+				teamDiffLayer[z].Up()
+			}
+
+			for z := teamDiffLen - 1; z > 0; z-- {
+				teamDiffLayer[z].Down()
+				d, err := truncLayer[z].Up()
+				if err != nil {
+					return 0, false, err
+				}
+				delta = math.Max(delta, d)
+				teamDiffLayer[z].SetPointer(0)
+				teamDiffLayer[z].Up()
+			}
+		}
+
+		inspect(index)
+
+		// Repeat until too small update
+		if delta <= MinDelta {
+			return iterations, true, nil
+		}
+	}
+
+	return iterations, false, nil
+}
+
+// runResidualSchedule updates one team difference at a time, picking the one
+// with the largest pending change. A team difference gets pending change
+// when another one updates the team performance shared with it.
+func (s *TrueSkill) runResidualSchedule(
+	teamDiffLayer []*factorgraph.SumFactor,
+	truncLayer []*factorgraph.TruncateFactor,
+	constraints []Constraint,
+	inspect func(iteration int),
+) (int, bool, error) {
+	teamDiffLen := len(teamDiffLayer)
+
+	byTeam := make(map[int][]int)
+	for z, c := range constraints {
+		byTeam[c.Winner] = append(byTeam[c.Winner], z)
+		byTeam[c.Loser] = append(byTeam[c.Loser], z)
+	}
+
+	residuals := make([]float64, teamDiffLen)
+	for z := range residuals {
+		residuals[z] = math.Inf(1)
+	}
+
+	// A sweep updates each team difference twice.
+	limit := (s.iterationLimit() + 1) * 2 * teamDiffLen
+
+	steps := 0
+	for steps < limit {
+		z := 0
+		for i, r := range residuals {
+			if r > residuals[z] {
+				z = i
+			}
+		}
+
+		if residuals[z] <= MinDelta {
+			return steps, true, nil
+		}
+
+		teamDiffLayer[z].Down()
+		if _, err := truncLayer[z].Up(); err != nil {
+			return 0, false, err
+		}
+		teamDiffLayer[z].SetPointer(0)
+		d0, _ := teamDiffLayer[z].Up()
+		d1, _ := teamDiffLayer[z].Up()
+
+		residuals[z] = 0
+		for _, n := range byTeam[constraints[z].Winner] {
+			if n != z {
+				residuals[n] = math.Max(residuals[n], d0)
+			}
+		}
+		for _, n := range byTeam[constraints[z].Loser] {
+			if n != z {
+				residuals[n] = math.Max(residuals[n], d1)
+			}
+		}
+
+		inspect(steps)
+		steps++
+	}
+
+	return steps, false, nil
+}
+
+// isChain reports whether each constraint compares the groups next to each other.
+func isChain(constraints []Constraint) bool {
+	for i, c := range constraints {
+		if c.Winner != i || c.Loser != i+1 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package trueskill_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gami/go-trueskill"
+)
+
+func chain(n int) [][]*trueskill.Rating {
+	groups := make([][]*trueskill.Rating, 0, n)
+	for i := 0; i < n; i++ {
+		groups = append(groups, []*trueskill.Rating{
+			trueskill.NewRating(25, 25.0/3, 1),
+			trueskill.NewRating(20+float64(i%7), 3+float64(i%5), 1),
+		})
+	}
+	return groups
+}
+
+func TestScheduleConvergesOn64TeamChain(t *testing.T) {
+	want, err := trueskill.NewTrueSkill().Rate(chain(64))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		damping  float64
+		schedule trueskill.Schedule
+	}{
+		{"damped sweep", 0.5, trueskill.SweepSchedule},
+		{"residual", 0, trueskill.ResidualSchedule},
+		{"damped residual", 0.5, trueskill.ResidualSchedule},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := trueskill.NewTrueSkill(
+				trueskill.Damping(tt.damping),
+				trueskill.UseSchedule(tt.schedule),
+			)
+
+			var trace trueskill.Trace
+			got, err := ts.Rate(chain(64), trueskill.Explain(&trace))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := range want {
+				for j := range want[i] {
+					if math.Abs(got[i][j].Mu-want[i][j].Mu) > 0.01 || math.Abs(got[i][j].Sigma-want[i][j].Sigma) > 0.01 {
+						t.Errorf("team=%d member=%d got=%+v want=%+v iterations=%d", i, j, got[i][j], want[i][j], trace.Iterations)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	for _, ts := range []*trueskill.TrueSkill{
+		trueskill.NewTrueSkill(trueskill.Damping(-0.1)),
+		trueskill.NewTrueSkill(trueskill.Damping(1)),
+		trueskill.NewTrueSkill(trueskill.Damping(1.5)),
+		trueskill.NewTrueSkill(trueskill.UseSchedule(trueskill.Schedule(7))),
+	} {
+		if _, err := ts.Rate(chain(3)); err == nil {
+			t.Errorf("%v must be an error", ts.Params())
+		}
+		if _, err := ts.Preview(chain(3)); err == nil {
+			t.Errorf("%v must be an error for Preview", ts.Params())
+		}
+	}
+}
+
+func TestScheduleReportsConvergence(t *testing.T) {
+	var trace trueskill.Trace
+	if _, err := trueskill.NewTrueSkill().Rate(chain(3), trueskill.Explain(&trace)); err != nil {
+		t.Fatal(err)
+	}
+	if !trace.Converged {
+		t.Errorf("3 teams must converge, got %d iterations", trace.Iterations)
+	}
+
+	ts := trueskill.NewTrueSkill(trueskill.Damping(0.9))
+	if _, err := ts.Rate(chain(64), trueskill.Explain(&trace)); err != nil {
+		t.Fatal(err)
+	}
+	// 10 / (1 - 0.9) sweeps after the first are not enough for 64 teams.
+	if trace.Converged || trace.Iterations != 101 {
+		t.Errorf("got %d iterations, converged %v", trace.Iterations, trace.Converged)
+	}
+}
@@ -0,0 +1,118 @@
+// Package season resets ratings at season boundaries.
+package season
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gami/go-trueskill"
+)
+
+// Strategy returns the rating of a player for the next season.
+type Strategy func(r *trueskill.Rating) *trueskill.Rating
+
+// Hard resets every rating to CreateRating of the rater.
+func Hard(rater trueskill.Rater) Strategy {
+	return func(r *trueskill.Rating) *trueskill.Rating {
+		return rater.CreateRating()
+	}
+}
+
+// Soft shrinks the distance of mu from the initial mu of the rater by the
+// factor between 0 and 1, and raises sigma by adding the variance of
+// inflation. Inflation stops at the initial sigma, and a sigma already above
+// it is kept as it is.
+func Soft(rater trueskill.Rater, factor float64, inflation float64) (Strategy, error) {
+	if factor < 0 || factor > 1 {
+		return nil, fmt.Errorf("factor must be in [0, 1], got %v", factor)
+	}
+
+	return func(r *trueskill.Rating) *trueskill.Rating {
+		initial := rater.CreateRating()
+		reset := *r
+		reset.Mu = initial.Mu + factor*(r.Mu-initial.Mu)
+		if r.Sigma < initial.Sigma {
+			reset.Sigma = math.Min(math.Sqrt(r.Sigma*r.Sigma+inflation*inflation), initial.Sigma)
+		}
+		return &reset
+	}, nil
+}
+
+// Keep carries ratings over as they are.
+func Keep() Strategy {
+	return func(r *trueskill.Rating) *trueskill.Rating {
+		kept := *r
+		return &kept
+	}
+}
+
+// Distribution summarizes ratings.
+type Distribution struct {
+	Count     int     `json:"count"`
+	MeanMu    float64 `json:"mean_mu"`
+	StdMu     float64 `json:"std_mu"`
+	MinMu     float64 `json:"min_mu"`
+	MaxMu     float64 `json:"max_mu"`
+	MeanSigma float64 `json:"mean_sigma"`
+}
+
+// Describe returns the distribution of the ratings.
+func Describe(ratings map[string]*trueskill.Rating) Distribution {
+	d := Distribution{Count: len(ratings)}
+	if d.Count == 0 {
+		return d
+	}
+
+	d.MinMu = math.Inf(1)
+	d.MaxMu = math.Inf(-1)
+	for _, r := range ratings {
+		d.MeanMu += r.Mu
+		d.MeanSigma += r.Sigma
+		d.MinMu = math.Min(d.MinMu, r.Mu)
+		d.MaxMu = math.Max(d.MaxMu, r.Mu)
+	}
+	d.MeanMu /= float64(d.Count)
+	d.MeanSigma /= float64(d.Count)
+
+	for _, r := range ratings {
+		d.StdMu += (r.Mu - d.MeanMu) * (r.Mu - d.MeanMu)
+	}
+	d.StdMu = math.Sqrt(d.StdMu / float64(d.Count))
+
+	return d
+}
+
+// Report is the distribution of ratings before and after a reset.
+type Report struct {
+	Before Distribution `json:"before"`
+	After  Distribution `json:"after"`
+}
+
+// Reset applies the strategy to every rating and returns the new ratings.
+// The given ratings are not modified.
+func Reset(ratings map[string]*trueskill.Rating, s Strategy) (map[string]*trueskill.Rating, *Report) {
+	reset := make(map[string]*trueskill.Rating, len(ratings))
+	for id, r := range ratings {
+		reset[id] = s(r)
+	}
+
+	return reset, &Report{
+		Before: Describe(ratings),
+		After:  Describe(reset),
+	}
+}
+
+// Policy is the carry-over strategy of each environment by name, such as
+// a game mode.
+type Policy map[string]Strategy
+
+// Reset applies the strategy of the environment.
+func (p Policy) Reset(env string, ratings map[string]*trueskill.Rating) (map[string]*trueskill.Rating, *Report, error) {
+	s, ok := p[env]
+	if !ok {
+		return nil, nil, fmt.Errorf("no strategy for environment %q", env)
+	}
+
+	reset, report := Reset(ratings, s)
+	return reset, report, nil
+}
@@ -0,0 +1,76 @@
+package season_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/season"
+)
+
+func TestReset(t *testing.T) {
+	env := trueskill.NewTrueSkill()
+	ratings := map[string]*trueskill.Rating{
+		"a": trueskill.NewRating(40, 2, 1),
+		"b": trueskill.NewRating(10, 3, 1),
+		"c": trueskill.NewRating(25, 8, 1),
+	}
+
+	ranked, err := season.Soft(env, 0.5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := season.Policy{
+		"ranked": ranked,
+		"casual": season.Hard(env),
+	}
+
+	soft, report, err := policy.Reset("ranked", ratings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if soft["a"].Mu != 32.5 || soft["b"].Mu != 17.5 || math.Abs(soft["a"].Sigma-math.Sqrt(13)) > 1e-12 {
+		t.Errorf("got %+v %+v", soft["a"], soft["b"])
+	}
+	if soft["c"].Sigma != env.CreateRating().Sigma {
+		t.Errorf("sigma must not exceed the initial sigma, got %v", soft["c"].Sigma)
+	}
+	if ratings["a"].Mu != 40 {
+		t.Error("the given ratings must not change")
+	}
+	if report.Before.StdMu != 2*report.After.StdMu || report.Before.MeanMu != report.After.MeanMu || report.After.MeanSigma <= report.Before.MeanSigma {
+		t.Errorf("got %+v", report)
+	}
+
+	hard, report, err := policy.Reset("casual", ratings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *hard["a"] != *env.CreateRating() || report.After.StdMu != 0 || report.After.MaxMu != 25 {
+		t.Errorf("got %+v, %+v", hard["a"], report)
+	}
+
+	if _, _, err := policy.Reset("arcade", ratings); err == nil {
+		t.Error("unknown environment must be an error")
+	}
+}
+
+func TestSoftKeepsHighSigma(t *testing.T) {
+	env := trueskill.NewTrueSkill()
+	soft, err := season.Soft(env, 0.5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A sigma above the initial one, as after tau inflation, never drops.
+	r := soft(trueskill.NewRating(30, 9, 1))
+	if r.Sigma != 9 || r.Mu != 27.5 {
+		t.Errorf("got %+v", r)
+	}
+
+	for _, factor := range []float64{-0.1, 1.5} {
+		if _, err := season.Soft(env, factor, 3); err == nil {
+			t.Errorf("factor %v must be an error", factor)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package simulation
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/gami/go-trueskill"
+)
+
+// Policy forms the teams of the next match. ratings has every player.
+type Policy interface {
+	Match(rnd *rand.Rand, players []*Player, ratings map[string]*trueskill.Rating, teams int, size int) [][]*Player
+}
+
+// RandomPolicy picks players at random.
+type RandomPolicy struct{}
+
+func (RandomPolicy) Match(rnd *rand.Rand, players []*Player, ratings map[string]*trueskill.Rating, teams int, size int) [][]*Player {
+	picked := make([]*Player, 0, teams*size)
+	for _, i := range rnd.Perm(len(players))[:teams*size] {
+		picked = append(picked, players[i])
+	}
+	return split(picked, teams, size)
+}
+
+// SkillPolicy picks a random run of players next to each other by rating
+// mean, then deals them into teams in snake order so that the teams are
+// balanced.
+type SkillPolicy struct{}
+
+func (SkillPolicy) Match(rnd *rand.Rand, players []*Player, ratings map[string]*trueskill.Rating, teams int, size int) [][]*Player {
+	sorted := make([]*Player, len(players))
+	copy(sorted, players)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return ratings[sorted[i].ID].Mu > ratings[sorted[j].ID].Mu
+	})
+
+	n := teams * size
+	start := rnd.Intn(len(sorted) - n + 1)
+	picked := sorted[start : start+n]
+
+	result := make([][]*Player, teams)
+	for i, p := range picked {
+		round := i / teams
+		t := i % teams
+		if round%2 == 1 {
+			t = teams - 1 - t
+		}
+		result[t] = append(result[t], p)
+	}
+	return result
+}
+
+func split(players []*Player, teams int, size int) [][]*Player {
+	result := make([][]*Player, 0, teams)
+	for t := 0; t < teams; t++ {
+		result = append(result, players[t*size:(t+1)*size])
+	}
+	return result
+}
@@ -0,0 +1,261 @@
+// Package simulation generates synthetic players and matches to experiment with rating systems.
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/gami/go-trueskill"
+)
+
+// Player is a synthetic player with a hidden true skill.
+type Player struct {
+	ID    string
+	Skill float64
+}
+
+// Sample is the accuracy of ratings after some matches.
+type Sample struct {
+	Matches   int     `json:"matches"`
+	RankError float64 `json:"rank_error"` // the ratio of player pairs ordered differently by ratings and by true skills.
+	MeanSigma float64 `json:"mean_sigma"`
+}
+
+// Report is the result of a simulation.
+type Report struct {
+	Samples     []Sample
+	ConvergedAt int // the number of matches when the rank error first reached the threshold, or -1.
+	Players     []*Player
+	Ratings     map[string]*trueskill.Rating
+	Matches     []*trueskill.Match
+}
+
+// Simulator plays synthetic matches and rates them by TrueSkill.
+type Simulator struct {
+	env            *trueskill.TrueSkill
+	players        int
+	skillMean      float64
+	skillDeviation float64
+	drift          float64
+	teams          int
+	teamSize       int
+	policy         Policy
+	interval       int
+	threshold      float64
+	seed           int64
+}
+
+type option func(*Simulator)
+
+func New(env *trueskill.TrueSkill, options ...option) *Simulator {
+	prior := env.CreateRating()
+
+	s := &Simulator{
+		env:            env,
+		players:        100,
+		skillMean:      prior.Mu,
+		skillDeviation: prior.Sigma,
+		teams:          2,
+		teamSize:       1,
+		policy:         RandomPolicy{},
+		interval:       100,
+		threshold:      0.1,
+		seed:           1,
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	return s
+}
+
+// Players is the size of the population. The default is 100.
+func Players(n int) option {
+	return func(s *Simulator) {
+		s.players = n
+	}
+}
+
+// SkillDistribution is the normal distribution of true skills. The default is
+// the prior of the environment.
+func SkillDistribution(mean float64, deviation float64) option {
+	return func(s *Simulator) {
+		s.skillMean = mean
+		s.skillDeviation = deviation
+	}
+}
+
+// Drift is the standard deviation of a change of a true skill after each match.
+func Drift(v float64) option {
+	return func(s *Simulator) {
+		s.drift = v
+	}
+}
+
+// Teams is the number of teams in a match and the number of players in a team.
+func Teams(teams int, size int) option {
+	return func(s *Simulator) {
+		s.teams = teams
+		s.teamSize = size
+	}
+}
+
+// WithPolicy sets the matchmaking policy. The default is RandomPolicy.
+func WithPolicy(p Policy) option {
+	return func(s *Simulator) {
+		s.policy = p
+	}
+}
+
+// SampleInterval is the number of matches between samples. The default is 100.
+func SampleInterval(n int) option {
+	return func(s *Simulator) {
+		s.interval = n
+	}
+}
+
+// Threshold is the rank error regarded as converged. The default is 0.1.
+func Threshold(v float64) option {
+	return func(s *Simulator) {
+		s.threshold = v
+	}
+}
+
+// Seed makes the simulation reproducible. The default is 1.
+func Seed(v int64) option {
+	return func(s *Simulator) {
+		s.seed = v
+	}
+}
+
+// Run plays the given number of matches.
+func (s *Simulator) Run(matches int) (*Report, error) {
+	if s.teams < 2 || s.teamSize < 1 {
+		return nil, errors.New("need multiple teams of at least one player")
+	}
+	if s.players < s.teams*s.teamSize {
+		return nil, fmt.Errorf("need at least %d players", s.teams*s.teamSize)
+	}
+	if s.interval < 1 {
+		return nil, errors.New("sample interval must be positive")
+	}
+
+	rnd := rand.New(rand.NewSource(s.seed))
+
+	players := make([]*Player, 0, s.players)
+	ratings := make(map[string]*trueskill.Rating, s.players)
+	for i := 0; i < s.players; i++ {
+		p := &Player{
+			ID:    fmt.Sprintf("p%d", i),
+			Skill: s.skillMean + rnd.NormFloat64()*s.skillDeviation,
+		}
+		players = append(players, p)
+		ratings[p.ID] = s.env.CreateRating()
+	}
+
+	r := &Report{
+		ConvergedAt: -1,
+		Players:     players,
+		Ratings:     ratings,
+		Matches:     make([]*trueskill.Match, 0, matches),
+	}
+
+	for i := 0; i < matches; i++ {
+		teams := s.policy.Match(rnd, players, r.Ratings, s.teams, s.teamSize)
+		m := s.play(rnd, i, teams)
+
+		rated, err := s.env.RateMatch(m, r.Ratings)
+		if err != nil {
+			return nil, err
+		}
+		for id, rating := range rated {
+			r.Ratings[id] = rating
+		}
+		r.Matches = append(r.Matches, m)
+
+		if s.drift > 0 {
+			for _, team := range teams {
+				for _, p := range team {
+					p.Skill += rnd.NormFloat64() * s.drift
+				}
+			}
+		}
+
+		if (i+1)%s.interval == 0 || i == matches-1 {
+			sample := s.sample(i+1, players, r.Ratings)
+			r.Samples = append(r.Samples, sample)
+			if r.ConvergedAt < 0 && sample.RankError <= s.threshold {
+				r.ConvergedAt = sample.Matches
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// play samples the outcome from the performance model of the environment.
+func (s *Simulator) play(rnd *rand.Rand, index int, teams [][]*Player) *trueskill.Match {
+	m := &trueskill.Match{
+		ID:    fmt.Sprintf("m%d", index),
+		Time:  time.Unix(int64(index), 0),
+		Teams: make([][]string, 0, len(teams)),
+		Ranks: make([]int, len(teams)),
+	}
+
+	perfs := make([]float64, 0, len(teams))
+	for _, team := range teams {
+		ids := make([]string, 0, len(team))
+		perf := 0.0
+		for _, p := range team {
+			ids = append(ids, p.ID)
+			perf += p.Skill + rnd.NormFloat64()*s.env.Beta()
+		}
+		m.Teams = append(m.Teams, ids)
+		perfs = append(perfs, perf)
+	}
+
+	order := make([]int, len(teams))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return perfs[order[a]] > perfs[order[b]]
+	})
+
+	margin := s.env.DrawMargin(2 * s.teamSize)
+	for i := 1; i < len(order); i++ {
+		prev, cur := order[i-1], order[i]
+		m.Ranks[cur] = i
+		if perfs[prev]-perfs[cur] <= margin {
+			m.Ranks[cur] = m.Ranks[prev]
+		}
+	}
+
+	return m
+}
+
+func (s *Simulator) sample(matches int, players []*Player, ratings map[string]*trueskill.Rating) Sample {
+	pairs := 0
+	discordant := 0
+	sigma := 0.0
+	for i, a := range players {
+		sigma += ratings[a.ID].Sigma
+		for _, b := range players[i+1:] {
+			pairs++
+			if (a.Skill-b.Skill)*(ratings[a.ID].Mu-ratings[b.ID].Mu) <= 0 {
+				discordant++
+			}
+		}
+	}
+
+	return Sample{
+		Matches:   matches,
+		RankError: float64(discordant) / math.Max(float64(pairs), 1),
+		MeanSigma: sigma / float64(len(players)),
+	}
+}
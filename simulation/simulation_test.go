@@ -0,0 +1,48 @@
+package simulation_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/simulation"
+)
+
+func TestRun(t *testing.T) {
+	ts := trueskill.NewTrueSkill()
+
+	for _, policy := range []simulation.Policy{simulation.RandomPolicy{}, simulation.SkillPolicy{}} {
+		sim := simulation.New(ts,
+			simulation.Players(20),
+			simulation.Teams(2, 2),
+			simulation.WithPolicy(policy),
+			simulation.SampleInterval(50),
+			simulation.Threshold(0.2),
+		)
+
+		r, err := sim.Run(500)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(r.Matches) != 500 || len(r.Samples) != 10 {
+			t.Fatalf("got %d matches and %d samples", len(r.Matches), len(r.Samples))
+		}
+
+		first, last := r.Samples[0], r.Samples[len(r.Samples)-1]
+		if last.RankError >= first.RankError || last.MeanSigma >= first.MeanSigma {
+			t.Errorf("%T: ratings must converge, first=%+v last=%+v", policy, first, last)
+		}
+		if r.ConvergedAt < 0 {
+			t.Errorf("%T: rank error must reach the threshold, samples=%+v", policy, r.Samples)
+		}
+
+		again, err := sim.Run(500)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(again.Samples, r.Samples) {
+			t.Errorf("%T: the same seed must reproduce the simulation", policy)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gami/go-trueskill"
+)
+
+// Applied is a match applied to a store.
+type Applied struct {
+	Match    *trueskill.Match
+	Before   map[string]*Record // records read before rating. Missing for new players.
+	After    map[string]*Record // records written by rating.
+	Attempts int
+}
+
+type applyConfig struct {
+	retries int
+	claim   string
+	value   func(a *Applied) ([]byte, error)
+}
+
+type applyOption func(*applyConfig)
+
+// Retries limits the number of retries on a version conflict. The default
+// is 10.
+func Retries(n int) applyOption {
+	return func(c *applyConfig) {
+		c.retries = n
+	}
+}
+
+// Claim writes a marker at key atomically with the ratings, and makes Apply
+// fail with ErrDuplicate if the marker exists. It makes applying a match
+// idempotent when the key is unique to the match. The value of the marker is
+// built by value from the result of Apply, or is empty if value is nil.
+func Claim(key string, value func(a *Applied) ([]byte, error)) applyOption {
+	return func(c *applyConfig) {
+		c.claim = key
+		c.value = value
+	}
+}
+
+// Apply reads the ratings of the players in the match, rates it and writes
+// the new ratings only if no player has been updated since the read. On a
+// conflict it starts over from the read, so each new rating is always based
+// on the version it replaces.
+func Apply(s Store, rater trueskill.Rater, m *trueskill.Match, options ...applyOption) (*Applied, error) {
+	c := &applyConfig{
+		retries: 10,
+	}
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	players := m.Players()
+
+	for attempt := 1; attempt <= c.retries+1; attempt++ {
+		if c.claim != "" {
+			_, err := s.GetMarker(c.claim)
+			if err == nil {
+				return nil, fmt.Errorf("match %q: %w", m.ID, ErrDuplicate)
+			}
+			if !errors.Is(err, ErrNotFound) {
+				return nil, err
+			}
+		}
+
+		before, err := s.BatchGet(players)
+		if err != nil {
+			return nil, err
+		}
+
+		ratings := make(map[string]*trueskill.Rating, len(before))
+		for id, r := range before {
+			rating := r.Rating
+			ratings[id] = &rating
+		}
+
+		rated, err := rater.RateMatch(m, ratings)
+		if err != nil {
+			return nil, err
+		}
+
+		updates := make([]Update, 0, len(players))
+		for _, id := range players {
+			u := Update{PlayerID: id, Rating: *rated[id]}
+			if r, ok := before[id]; ok {
+				u.Version = r.Version
+			}
+			updates = append(updates, u)
+		}
+
+		// The records a successful swap writes are known in advance, so
+		// the marker can carry them.
+		applied := &Applied{
+			Match:    m,
+			Before:   before,
+			After:    make(map[string]*Record, len(updates)),
+			Attempts: attempt,
+		}
+		for _, u := range updates {
+			applied.After[u.PlayerID] = &Record{PlayerID: u.PlayerID, Rating: u.Rating, Version: u.Version + 1}
+		}
+
+		var markers []Marker
+		if c.claim != "" {
+			marker := Marker{Key: c.claim}
+			if c.value != nil {
+				if marker.Value, err = c.value(applied); err != nil {
+					return nil, err
+				}
+			}
+			markers = append(markers, marker)
+		}
+
+		records, err := s.CompareAndSwap(updates, markers...)
+		if errors.Is(err, ErrConflict) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range records {
+			applied.After[r.PlayerID] = r
+		}
+		return applied, nil
+	}
+
+	return nil, fmt.Errorf("match %q: %w after %d attempts", m.ID, ErrConflict, c.retries+1)
+}
@@ -0,0 +1,72 @@
+package store_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/store"
+)
+
+func TestApplyConcurrently(t *testing.T) {
+	s := store.NewMemoryStore()
+	env := trueskill.NewTrueSkill()
+
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m := &trueskill.Match{
+				ID:    fmt.Sprintf("m%d", i),
+				Teams: [][]string{{"shared"}, {fmt.Sprintf("p%d", i)}},
+			}
+			if _, err := store.Apply(s, env, m, store.Retries(n)); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// Every match updated the shared player once from the previous version.
+	r, err := s.Get("shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Version != n {
+		t.Errorf("got version %d, want %d", r.Version, n)
+	}
+}
+
+func TestApply(t *testing.T) {
+	s := store.NewMemoryStore()
+	env := trueskill.NewTrueSkill()
+
+	if _, err := s.Put("a", *trueskill.NewRating(30, 5, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &trueskill.Match{ID: "m", Teams: [][]string{{"a"}, {"b"}}}
+	applied, err := store.Apply(s, env, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := env.Rate1v1(trueskill.NewRating(30, 5, 1), env.CreateRating())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied.Attempts != 1 || applied.Before["a"].Version != 1 || applied.After["a"].Version != 2 || applied.After["b"].Version != 1 {
+		t.Errorf("got %+v", applied)
+	}
+	if applied.After["a"].Rating.Mu != want[0].Mu || applied.After["b"].Rating.Mu != want[1].Mu {
+		t.Errorf("got %+v %+v", applied.After["a"], applied.After["b"])
+	}
+}
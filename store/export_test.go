@@ -0,0 +1,8 @@
+package store
+
+import "os"
+
+// SetSyncFile replaces the function which flushes writes of the store.
+func SetSyncFile(s *FileStore, fn func(f *os.File) error) {
+	s.syncFile = fn
+}
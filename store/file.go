@@ -0,0 +1,357 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gami/go-trueskill"
+)
+
+// FileStore keeps ratings in memory and appends every write to a log in a
+// single file. A line of the log is the CRC-32 of a batch of records and
+// markers in hex and the batch in JSON. A torn line at the end, left by a
+// crash during a write, is discarded on open.
+//
+// Compaction rewrites the file as a single batch of the current records and
+// markers into a temporary file, which atomically replaces the file by rename.
+type FileStore struct {
+	mu           sync.Mutex
+	mem          *MemoryStore
+	path         string
+	file         *os.File
+	sync         bool
+	compactAfter int
+	onCompact    func(err error)
+	batches      int   // the number of batches in the file.
+	size         int64 // the size of the file up to the last complete line.
+	syncFile     func(f *os.File) error
+	broken       error // set when a failed write cannot be rolled back.
+}
+
+var _ Store = (*FileStore)(nil)
+
+type option func(*FileStore)
+
+// Sync flushes each write to the disk before it returns. The default is
+// true.
+func Sync(v bool) option {
+	return func(s *FileStore) {
+		s.sync = v
+	}
+}
+
+// CompactAfter compacts the file when it has n batches. 0 disables
+// automatic compaction. The default is 1000.
+func CompactAfter(n int) option {
+	return func(s *FileStore) {
+		s.compactAfter = n
+	}
+}
+
+// OnCompactError is called when an automatic compaction after a write
+// fails. The write itself has committed, and compaction is tried again after
+// the next write.
+func OnCompactError(fn func(err error)) option {
+	return func(s *FileStore) {
+		s.onCompact = fn
+	}
+}
+
+// OpenFileStore opens the store at path, creating the file if missing.
+func OpenFileStore(path string, options ...option) (*FileStore, error) {
+	s := &FileStore{
+		mem:          NewMemoryStore(),
+		path:         path,
+		sync:         true,
+		compactAfter: 1000,
+		syncFile:     (*os.File).Sync,
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	// A compaction which crashed before rename leaves the file intact.
+	if err := os.Remove(s.compactPath()); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.load(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	s.file = f
+
+	return s, nil
+}
+
+// load replays the log and truncates a torn line at the end.
+func (s *FileStore) load(f *os.File) error {
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return err
+	}
+
+	offset := 0
+	for offset < len(data) {
+		end := bytes.IndexByte(data[offset:], '\n')
+		if end < 0 {
+			break
+		}
+		b, err := decode(data[offset : offset+end])
+		if err != nil {
+			if offset+end+1 < len(data) {
+				return fmt.Errorf("%s is corrupted at offset %d: %w", s.path, offset, err)
+			}
+			break
+		}
+		s.mem.apply(b.Records, b.Markers)
+		s.batches++
+		offset += end + 1
+	}
+
+	s.size = int64(offset)
+	if offset < len(data) {
+		return f.Truncate(s.size)
+	}
+	return nil
+}
+
+// batch is the records and the markers of a write.
+type batch struct {
+	Records []*Record `json:"records"`
+	Markers []Marker  `json:"markers,omitempty"`
+}
+
+func encode(b *batch) ([]byte, error) {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	line := make([]byte, 0, len(data)+10)
+	line = append(line, fmt.Sprintf("%08x ", crc32.ChecksumIEEE(data))...)
+	line = append(line, data...)
+	return append(line, '\n'), nil
+}
+
+func decode(line []byte) (*batch, error) {
+	if len(line) < 9 || line[8] != ' ' {
+		return nil, errors.New("malformed line")
+	}
+	sum, err := strconv.ParseUint(string(line[:8]), 16, 32)
+	if err != nil {
+		return nil, err
+	}
+	data := line[9:]
+	if crc32.ChecksumIEEE(data) != uint32(sum) {
+		return nil, errors.New("checksum mismatch")
+	}
+
+	b := &batch{}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (s *FileStore) Get(id string) (*Record, error) {
+	return s.mem.Get(id)
+}
+
+func (s *FileStore) BatchGet(ids []string) (map[string]*Record, error) {
+	return s.mem.BatchGet(ids)
+}
+
+func (s *FileStore) GetMarker(key string) ([]byte, error) {
+	return s.mem.GetMarker(key)
+}
+
+func (s *FileStore) Put(id string, r trueskill.Rating) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mem.mu.Lock()
+	records := s.mem.next([]Update{{PlayerID: id, Version: s.mem.version(id), Rating: r}})
+	err := s.write(&batch{Records: records})
+	s.mem.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	s.maybeCompact()
+	return records[0], nil
+}
+
+func (s *FileStore) CompareAndSwap(updates []Update, markers ...Marker) ([]*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mem.mu.Lock()
+	if err := s.mem.check(updates, markers); err != nil {
+		s.mem.mu.Unlock()
+		return nil, err
+	}
+	records := s.mem.next(updates)
+	err := s.write(&batch{Records: records, Markers: markers})
+	s.mem.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	s.maybeCompact()
+	return records, nil
+}
+
+// write appends the batch to the log, then applies it to memory. The caller
+// holds both locks.
+func (s *FileStore) write(b *batch) error {
+	if s.file == nil {
+		return errors.New("store is closed")
+	}
+	if s.broken != nil {
+		return s.broken
+	}
+
+	line, err := encode(b)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(line); err != nil {
+		return s.rollback(err)
+	}
+	if s.sync {
+		if err := s.syncFile(s.file); err != nil {
+			return s.rollback(err)
+		}
+	}
+
+	s.mem.apply(b.Records, b.Markers)
+	s.batches++
+	s.size += int64(len(line))
+	return nil
+}
+
+// rollback drops the line of a failed write, which may be partly or wholly in
+// the file, so that it neither comes back on open nor breaks the next line.
+// If that fails too, the store refuses further writes.
+func (s *FileStore) rollback(err error) error {
+	if truncErr := s.file.Truncate(s.size); truncErr != nil {
+		s.broken = fmt.Errorf("%s cannot drop a failed write: %v", s.path, truncErr)
+	}
+	return err
+}
+
+// maybeCompact compacts the file if it has enough batches. A failure is only
+// reported to OnCompactError, since the write before it has committed.
+func (s *FileStore) maybeCompact() {
+	if s.compactAfter <= 0 || s.batches < s.compactAfter {
+		return
+	}
+	if err := s.compact(); err != nil && s.onCompact != nil {
+		s.onCompact(err)
+	}
+}
+
+// Compact rewrites the file with only the current records.
+func (s *FileStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.compact()
+}
+
+func (s *FileStore) compactPath() string {
+	return s.path + ".compact"
+}
+
+func (s *FileStore) compact() error {
+	if s.file == nil {
+		return errors.New("store is closed")
+	}
+
+	s.mem.mu.RLock()
+	records := make([]*Record, 0, len(s.mem.records))
+	for _, r := range s.mem.records {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].PlayerID < records[j].PlayerID
+	})
+	markers := make([]Marker, 0, len(s.mem.markers))
+	for key, value := range s.mem.markers {
+		markers = append(markers, Marker{Key: key, Value: value})
+	}
+	sort.Slice(markers, func(i, j int) bool {
+		return markers[i].Key < markers[j].Key
+	})
+	line, err := encode(&batch{Records: records, Markers: markers})
+	s.mem.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	// The temporary file is opened for appending, so that it becomes the log
+	// by rename without opening the file again.
+	tmp, err := os.OpenFile(s.compactPath(), os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(line); err != nil {
+		tmp.Close()
+		os.Remove(s.compactPath())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(s.compactPath())
+		return err
+	}
+
+	if err := os.Rename(s.compactPath(), s.path); err != nil {
+		tmp.Close()
+		os.Remove(s.compactPath())
+		return err
+	}
+
+	s.file.Close()
+	s.file = tmp
+	s.batches = 1
+	s.size = int64(len(line))
+
+	return syncDir(filepath.Dir(s.path))
+}
+
+// syncDir makes a rename in the directory durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
@@ -0,0 +1,143 @@
+package store
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gami/go-trueskill"
+)
+
+// MemoryStore keeps ratings in memory.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string]*Record
+	markers map[string][]byte
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: make(map[string]*Record),
+		markers: make(map[string][]byte),
+	}
+}
+
+func (s *MemoryStore) Get(id string) (*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.records[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *r
+	return &copied, nil
+}
+
+func (s *MemoryStore) BatchGet(ids []string) (map[string]*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string]*Record, len(ids))
+	for _, id := range ids {
+		if r, ok := s.records[id]; ok {
+			copied := *r
+			result[id] = &copied
+		}
+	}
+	return result, nil
+}
+
+func (s *MemoryStore) Put(id string, r trueskill.Rating) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := s.next([]Update{{PlayerID: id, Version: s.version(id), Rating: r}})
+	s.apply(records, nil)
+	return records[0], nil
+}
+
+func (s *MemoryStore) CompareAndSwap(updates []Update, markers ...Marker) ([]*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(updates, markers); err != nil {
+		return nil, err
+	}
+	records := s.next(updates)
+	s.apply(records, markers)
+	return records, nil
+}
+
+func (s *MemoryStore) GetMarker(key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.markers[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), v...), nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// version returns the current version of the player, or 0 if absent.
+func (s *MemoryStore) version(id string) uint64 {
+	if r, ok := s.records[id]; ok {
+		return r.Version
+	}
+	return 0
+}
+
+// check verifies the versions of the updates and that the markers are new.
+// The caller holds the lock.
+func (s *MemoryStore) check(updates []Update, markers []Marker) error {
+	keys := make(map[string]bool, len(markers))
+	for _, m := range markers {
+		if _, ok := s.markers[m.Key]; ok || keys[m.Key] {
+			return fmt.Errorf("%w: %s", ErrDuplicate, m.Key)
+		}
+		keys[m.Key] = true
+	}
+
+	seen := make(map[string]bool, len(updates))
+	for _, u := range updates {
+		if seen[u.PlayerID] {
+			return fmt.Errorf("player %s is updated twice", u.PlayerID)
+		}
+		seen[u.PlayerID] = true
+
+		if v := s.version(u.PlayerID); v != u.Version {
+			return fmt.Errorf("%w: player %s is at version %d, not %d", ErrConflict, u.PlayerID, v, u.Version)
+		}
+	}
+	return nil
+}
+
+// next returns the records written by the checked updates.
+func (s *MemoryStore) next(updates []Update) []*Record {
+	records := make([]*Record, 0, len(updates))
+	for _, u := range updates {
+		records = append(records, &Record{
+			PlayerID: u.PlayerID,
+			Rating:   u.Rating,
+			Version:  u.Version + 1,
+		})
+	}
+	return records
+}
+
+// apply stores the records and the markers. The caller holds the lock.
+func (s *MemoryStore) apply(records []*Record, markers []Marker) {
+	for _, r := range records {
+		copied := *r
+		s.records[r.PlayerID] = &copied
+	}
+	for _, m := range markers {
+		s.markers[m.Key] = append([]byte(nil), m.Value...)
+	}
+}
@@ -0,0 +1,61 @@
+// Package store persists ratings by player ID with versions for optimistic
+// concurrency.
+package store
+
+import (
+	"errors"
+
+	"github.com/gami/go-trueskill"
+)
+
+var (
+	// ErrNotFound is returned when the player has no rating, or the marker
+	// does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a version does not match the stored one.
+	ErrConflict = errors.New("version conflict")
+	// ErrDuplicate is returned when a marker already exists.
+	ErrDuplicate = errors.New("marker already exists")
+)
+
+// Record is a stored rating. The version starts from 1 and increases by 1
+// on each write.
+type Record struct {
+	PlayerID string           `json:"player_id"`
+	Rating   trueskill.Rating `json:"rating"`
+	Version  uint64           `json:"version"`
+}
+
+// Update is a conditional write of a rating. Version is the version which
+// the stored rating must have, or 0 if the player must have no rating.
+type Update struct {
+	PlayerID string
+	Version  uint64
+	Rating   trueskill.Rating
+}
+
+// Marker is a write-once value kept in its own keyspace apart from ratings,
+// such as the result of a processed match.
+type Marker struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// Store keeps ratings by player ID.
+type Store interface {
+	// Get returns the record of the player or ErrNotFound.
+	Get(id string) (*Record, error)
+	// BatchGet returns the records of the players who have ratings.
+	BatchGet(ids []string) (map[string]*Record, error)
+	// Put writes the rating regardless of the version and returns the new
+	// record.
+	Put(id string, r trueskill.Rating) (*Record, error)
+	// CompareAndSwap writes all updates and markers if every version
+	// matches and no marker exists. Otherwise it writes nothing and returns
+	// an error wrapping ErrConflict or ErrDuplicate.
+	CompareAndSwap(updates []Update, markers ...Marker) ([]*Record, error)
+	// GetMarker returns the value of the marker or ErrNotFound.
+	GetMarker(key string) ([]byte, error)
+	// Close releases the store.
+	Close() error
+}
@@ -0,0 +1,252 @@
+package store_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/store"
+)
+
+func testStore(t *testing.T, s store.Store) {
+	if _, err := s.Get("a"); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("got %v", err)
+	}
+
+	r, err := s.Put("a", *trueskill.NewRating(25, 8, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Version != 1 {
+		t.Errorf("got version %d", r.Version)
+	}
+
+	records, err := s.CompareAndSwap([]store.Update{
+		{PlayerID: "a", Version: 1, Rating: *trueskill.NewRating(27, 7, 1)},
+		{PlayerID: "b", Version: 0, Rating: *trueskill.NewRating(23, 7, 1)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records[0].Version != 2 || records[1].Version != 1 {
+		t.Errorf("got %+v %+v", records[0], records[1])
+	}
+
+	// The stale version of a fails the whole batch.
+	_, err = s.CompareAndSwap([]store.Update{
+		{PlayerID: "b", Version: 1, Rating: *trueskill.NewRating(0, 1, 1)},
+		{PlayerID: "a", Version: 1, Rating: *trueskill.NewRating(0, 1, 1)},
+	})
+	if !errors.Is(err, store.ErrConflict) {
+		t.Fatalf("got %v", err)
+	}
+
+	// A marker is written with the ratings only once.
+	if _, err := s.CompareAndSwap([]store.Update{
+		{PlayerID: "b", Version: 1, Rating: *trueskill.NewRating(23, 7, 1)},
+	}, store.Marker{Key: "m", Value: []byte("done")}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.CompareAndSwap([]store.Update{
+		{PlayerID: "b", Version: 2, Rating: *trueskill.NewRating(0, 1, 1)},
+	}, store.Marker{Key: "m"})
+	if !errors.Is(err, store.ErrDuplicate) {
+		t.Fatalf("got %v", err)
+	}
+	if v, err := s.GetMarker("m"); err != nil || string(v) != "done" {
+		t.Errorf("got %q, %v", v, err)
+	}
+	if _, err := s.GetMarker("b"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("got %v", err)
+	}
+
+	got, err := s.BatchGet([]string{"a", "b", "c", "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["a"].Rating.Mu != 27 || got["b"].Rating.Mu != 23 || got["b"].Version != 2 {
+		t.Errorf("got %+v %+v", got["a"], got["b"])
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, store.NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ratings.log")
+
+	s, err := store.OpenFileStore(path, store.CompactAfter(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, s)
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A torn write at the end is discarded on open.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`0badc0de {"records":[{"player_id":"a"`)
+	f.Close()
+
+	s, err = store.OpenFileStore(path, store.CompactAfter(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := s.Get("a")
+	if err != nil || r.Version != 2 || r.Rating.Mu != 27 {
+		t.Fatalf("got %+v, %v", r, err)
+	}
+
+	// The third batch compacts the file to a single line.
+	if _, err := s.Put("c", *trueskill.NewRating(30, 5, 1)); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines != 1 {
+		t.Errorf("got %d lines after compaction", lines)
+	}
+	if _, err := s.Put("c", *trueskill.NewRating(31, 5, 1)); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s, err = store.OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	got, err := s.BatchGet([]string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got["c"].Version != 2 || got["c"].Rating.Mu != 31 || got["a"].Version != 2 {
+		t.Errorf("got %+v %+v %+v", got["a"], got["b"], got["c"])
+	}
+	if v, err := s.GetMarker("m"); err != nil || string(v) != "done" {
+		t.Errorf("got %q, %v after compaction", v, err)
+	}
+}
+
+func TestFileStoreCompactError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ratings.log")
+
+	var compactErr error
+	s, err := store.OpenFileStore(path, store.CompactAfter(1), store.OnCompactError(func(err error) {
+		compactErr = err
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A directory in place of the temporary file makes compaction fail.
+	if err := os.Mkdir(path+".compact", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Put("a", *trueskill.NewRating(25, 8, 1)); err != nil {
+		t.Fatalf("committed write must succeed, got %v", err)
+	}
+	if compactErr == nil {
+		t.Error("compaction error must be reported")
+	}
+
+	if err := os.Remove(path + ".compact"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Put("a", *trueskill.NewRating(26, 8, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Put("b", *trueskill.NewRating(20, 8, 1)); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s, err = store.OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	got, err := s.BatchGet([]string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["a"].Version != 2 || got["a"].Rating.Mu != 26 {
+		t.Errorf("got %+v %+v", got["a"], got["b"])
+	}
+}
+
+func TestFileStoreSyncError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ratings.log")
+
+	s, err := store.OpenFileStore(path, store.CompactAfter(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Put("a", *trueskill.NewRating(25, 8, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// A write which fails to sync is dropped from the file and memory.
+	store.SetSyncFile(s, func(f *os.File) error {
+		return errors.New("disk failure")
+	})
+	if _, err := s.Put("b", *trueskill.NewRating(20, 8, 1)); err == nil {
+		t.Fatal("failed sync must fail the write")
+	}
+	if _, err := s.Get("b"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("got %v", err)
+	}
+
+	store.SetSyncFile(s, (*os.File).Sync)
+	if _, err := s.Put("c", *trueskill.NewRating(30, 8, 1)); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines != 2 {
+		t.Errorf("got %d lines", lines)
+	}
+
+	s, err = store.OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	got, err := s.BatchGet([]string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["a"] == nil || got["c"] == nil {
+		t.Errorf("got %+v", got)
+	}
+}
@@ -0,0 +1,238 @@
+package trueskill
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gami/go-trueskill/factorgraph"
+	"github.com/gami/go-trueskill/mathmatics"
+)
+
+// maxHistoryIterations is the limit of forward and backward passes over time slices.
+const maxHistoryIterations = 30
+
+// SkillPoint is a smoothed skill of a player at a time.
+type SkillPoint struct {
+	Time   time.Time
+	Rating *Rating
+}
+
+// History is skill trajectories smoothed by TrueSkill Through Time.
+type History struct {
+	Iterations   int
+	Trajectories map[string][]SkillPoint // skill of each player at the time slices the player played in.
+}
+
+// Current returns the latest smoothed rating of the player, or nil if the player has not played.
+func (h *History) Current(id string) *Rating {
+	points := h.Trajectories[id]
+	if len(points) == 0 {
+		return nil
+	}
+	return points[len(points)-1].Rating
+}
+
+// timeSlice is the skill variables of players at a time.
+type timeSlice struct {
+	time    time.Time
+	ids     []string
+	skills  map[string]*factorgraph.Variable
+	prev    []*factorgraph.LikelihoodFactor // dynamics from the previous skills.
+	next    []*factorgraph.LikelihoodFactor // dynamics to the next skills.
+	matches []*matchLayers
+}
+
+// matchLayers is the factor graph of a match below the skills.
+type matchLayers struct {
+	perfLayer     []factorgraph.Factor
+	teamPerfLayer []*factorgraph.SumFactor
+	teamDiffLayer []*factorgraph.SumFactor
+	truncLayer    []*factorgraph.TruncateFactor
+	constraints   []Constraint
+}
+
+// RateHistory estimates skills from a complete match history by TrueSkill
+// Through Time. Matches at the same time form a time slice, and messages are
+// passed forward and backward across the slices until the skills converge,
+// so that early skills are also estimated from later matches. A skill drifts
+// by tau between time slices which the player plays in.
+func (s *TrueSkill) RateHistory(matches []*Match) (*History, error) {
+	if err := s.validateOptions(); err != nil {
+		return nil, err
+	}
+	for _, m := range matches {
+		if err := m.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	sorted := make([]*Match, len(matches))
+	copy(sorted, matches)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	priors := make([]*factorgraph.PriorFactor, 0)
+	latest := make(map[string]*factorgraph.Variable)
+	latestSlice := make(map[string]*timeSlice)
+	slices := make([]*timeSlice, 0)
+
+	for _, m := range sorted {
+		if len(slices) == 0 || !slices[len(slices)-1].time.Equal(m.Time) {
+			slices = append(slices, &timeSlice{
+				time:   m.Time,
+				skills: make(map[string]*factorgraph.Variable),
+			})
+		}
+		slice := slices[len(slices)-1]
+
+		skillVars := make([]*factorgraph.Variable, 0)
+		for _, id := range m.Players() {
+			v, ok := slice.skills[id]
+			if !ok {
+				v = factorgraph.NewVariable(mathmatics.NewGaussian(0, 0))
+				if last, ok := latest[id]; ok {
+					f := factorgraph.NewLikelihoodFactor(last, v, math.Pow(s.tau, 2))
+					slice.prev = append(slice.prev, f)
+					latestSlice[id].next = append(latestSlice[id].next, f)
+				} else {
+					priors = append(priors, factorgraph.NewPriorFactor(v, s.CreateRating().gaussian(), s.tau))
+				}
+				slice.skills[id] = v
+				slice.ids = append(slice.ids, id)
+				latest[id] = v
+				latestSlice[id] = slice
+			}
+			skillVars = append(skillVars, v)
+		}
+
+		slice.matches = append(slice.matches, s.buildMatchLayers(m, skillVars))
+	}
+
+	for _, f := range priors {
+		f.Down()
+	}
+
+	iterations := 0
+	for iterations < maxHistoryIterations {
+		iterations++
+		before := skillValues(slices)
+
+		for _, slice := range slices {
+			for _, f := range slice.prev {
+				f.Down()
+			}
+			if err := s.passSliceMessages(slice); err != nil {
+				return nil, err
+			}
+		}
+
+		for i := len(slices) - 1; i >= 0; i-- {
+			for _, f := range slices[i].next {
+				f.Up()
+			}
+			if err := s.passSliceMessages(slices[i]); err != nil {
+				return nil, err
+			}
+		}
+
+		delta := 0.0
+		for i, v := range skillValues(slices) {
+			delta = math.Max(delta, math.Abs(v-before[i]))
+		}
+		if delta <= MinDelta {
+			break
+		}
+	}
+
+	h := &History{
+		Iterations:   iterations,
+		Trajectories: make(map[string][]SkillPoint),
+	}
+	for _, slice := range slices {
+		for _, id := range slice.ids {
+			v := slice.skills[id]
+			h.Trajectories[id] = append(h.Trajectories[id], SkillPoint{
+				Time:   slice.time,
+				Rating: NewRating(v.Mu(), v.Sigma(), 1),
+			})
+		}
+	}
+
+	return h, nil
+}
+
+func (s *TrueSkill) buildMatchLayers(m *Match, skillVars []*factorgraph.Variable) *matchLayers {
+	// The groups are only used for the team sizes.
+	groups := make([][]*Rating, 0, len(m.Teams))
+	weights := make([]float64, 0, len(skillVars))
+	for _, team := range m.Teams {
+		groups = append(groups, make([]*Rating, len(team)))
+		for range team {
+			weights = append(weights, 1)
+		}
+	}
+
+	perfVars := make([]*factorgraph.Variable, 0, len(skillVars))
+	for range skillVars {
+		perfVars = append(perfVars, factorgraph.NewVariable(mathmatics.NewGaussian(0, 0)))
+	}
+
+	teamPerfVars := make([]*factorgraph.Variable, 0, len(m.Teams))
+	for range m.Teams {
+		teamPerfVars = append(teamPerfVars, factorgraph.NewVariable(mathmatics.NewGaussian(0, 0)))
+	}
+
+	constraints := m.Constraints()
+	teamDiffVars := make([]*factorgraph.Variable, 0, len(constraints))
+	for range constraints {
+		teamDiffVars = append(teamDiffVars, factorgraph.NewVariable(mathmatics.NewGaussian(0, 0)))
+	}
+
+	return &matchLayers{
+		perfLayer:     s.buildPerfLayer(skillVars, perfVars),
+		teamPerfLayer: s.buildTeamPerfLayer(teamPerfVars, perfVars, teamSizes(groups), weights),
+		teamDiffLayer: s.buildTeamDiffLayer(teamPerfVars, teamDiffVars, constraints),
+		truncLayer:    s.buildTruncLayer(teamDiffVars, constraints, groups),
+		constraints:   constraints,
+	}
+}
+
+// passSliceMessages runs every match of the time slice with the current skills.
+func (s *TrueSkill) passSliceMessages(slice *timeSlice) error {
+	for _, m := range slice.matches {
+		for _, f := range m.perfLayer {
+			f.Down()
+		}
+		for _, f := range m.teamPerfLayer {
+			f.Down()
+		}
+
+		_, _, err := s.passMessages(
+			m.perfLayer,
+			m.teamPerfLayer,
+			m.teamDiffLayer,
+			m.truncLayer,
+			m.constraints,
+			func(int) {},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// skillValues lists means and standard deviations of all skills to check convergence.
+func skillValues(slices []*timeSlice) []float64 {
+	values := make([]float64, 0)
+	for _, slice := range slices {
+		for _, id := range slice.ids {
+			v := slice.skills[id]
+			values = append(values, v.Mu(), v.Sigma())
+		}
+	}
+	return values
+}
@@ -0,0 +1,67 @@
+package trueskill_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gami/go-trueskill"
+)
+
+func TestRateHistorySingleMatch(t *testing.T) {
+	ts := trueskill.NewTrueSkill()
+
+	want, err := ts.Rate1v1(ts.CreateRating(), ts.CreateRating())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := ts.RateHistory([]*trueskill.Match{
+		{Time: time.Unix(0, 0), Teams: [][]string{{"a"}, {"b"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, id := range []string{"a", "b"} {
+		got := h.Current(id)
+		if math.Abs(got.Mu-want[i].Mu) > 1e-9 || math.Abs(got.Sigma-want[i].Sigma) > 1e-9 {
+			t.Errorf("player=%s got=%+v want=%+v", id, got, want[i])
+		}
+	}
+}
+
+func TestRateHistorySmoothsEarlySkills(t *testing.T) {
+	ts := trueskill.NewTrueSkill()
+
+	matches := make([]*trueskill.Match, 0)
+	for i := 0; i < 5; i++ {
+		matches = append(matches, &trueskill.Match{
+			Time:  time.Unix(int64(i), 0),
+			Teams: [][]string{{"a"}, {"b"}},
+		})
+	}
+
+	h, err := ts.RateHistory(matches)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(h.Trajectories["a"]) != len(matches) {
+		t.Fatalf("got %d points, want %d", len(h.Trajectories["a"]), len(matches))
+	}
+
+	online, err := ts.Rate1v1(ts.CreateRating(), ts.CreateRating())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := h.Trajectories["a"][0].Rating
+	if first.Mu <= online[0].Mu || first.Sigma >= online[0].Sigma {
+		t.Errorf("smoothed first skill %+v must be more certain than online %+v", first, online[0])
+	}
+
+	if _, err := ts.RateHistory([]*trueskill.Match{{Teams: [][]string{{"a"}, {"a"}}}}); err == nil {
+		t.Error("a player in both teams must be an error")
+	}
+}
@@ -0,0 +1,171 @@
+// Package tier maps ratings to divisions shown to players, with buffers
+// which keep a player from flickering between two divisions.
+package tier
+
+import (
+	"time"
+
+	"github.com/gami/go-trueskill"
+)
+
+// Division is a range of scores from Min up to the Min of the next division.
+type Division struct {
+	Name   string  `json:"name"`
+	Min    float64 `json:"min"`
+	Decays bool    `json:"decays"` // scores of inactive players in the division decay.
+}
+
+// DefaultDivisions are divisions for the default TrueSkill exposure, which
+// starts from 0.
+var DefaultDivisions = []Division{
+	{Name: "Bronze", Min: 0},
+	{Name: "Silver", Min: 10},
+	{Name: "Gold", Min: 17},
+	{Name: "Platinum", Min: 23},
+	{Name: "Diamond", Min: 29, Decays: true},
+	{Name: "Master", Min: 34, Decays: true},
+	{Name: "Grandmaster", Min: 38, Decays: true},
+}
+
+// Unplaced is the division index of a player who has not finished placement.
+const Unplaced = -1
+
+// Standing is the division of a player and what decides its change.
+type Standing struct {
+	Division   int       `json:"division"` // index of the division, or Unplaced.
+	Games      int       `json:"games"`
+	LastPlayed time.Time `json:"last_played"`
+}
+
+// NewStanding returns the standing of a new player.
+func NewStanding() Standing {
+	return Standing{Division: Unplaced}
+}
+
+// Tiers decides divisions from ratings.
+type Tiers struct {
+	score     func(r *trueskill.Rating) float64
+	divisions []Division
+	minGames  int
+	maxSigma  float64
+	promotion float64
+	demotion  float64
+	grace     time.Duration
+	perDay    float64
+}
+
+type option func(*Tiers)
+
+// New makes tiers which score ratings by Expose of the rater.
+func New(rater trueskill.Rater, options ...option) *Tiers {
+	t := &Tiers{
+		score:     rater.Expose,
+		divisions: DefaultDivisions,
+		minGames:  5,
+		promotion: 1,
+		demotion:  1,
+	}
+
+	for _, opt := range options {
+		opt(t)
+	}
+
+	return t
+}
+
+// Divisions sets the divisions in ascending order of Min. The default is
+// DefaultDivisions.
+func Divisions(divisions ...Division) option {
+	return func(t *Tiers) {
+		t.divisions = divisions
+	}
+}
+
+// Placement places a player after minGames games, or earlier once sigma
+// is at most maxSigma. 0 disables maxSigma. The default is 5 games.
+func Placement(minGames int, maxSigma float64) option {
+	return func(t *Tiers) {
+		t.minGames = minGames
+		t.maxSigma = maxSigma
+	}
+}
+
+// Buffer is how far above the next division a score must go to promote,
+// and how far below the current division to demote. The default is 1 and 1.
+func Buffer(promotion float64, demotion float64) option {
+	return func(t *Tiers) {
+		t.promotion = promotion
+		t.demotion = demotion
+	}
+}
+
+// Decay lowers the score of a player in a decaying division by perDay for
+// each day without a game after grace. It is disabled by default.
+func Decay(grace time.Duration, perDay float64) option {
+	return func(t *Tiers) {
+		t.grace = grace
+		t.perDay = perDay
+	}
+}
+
+// Division returns the division of the standing.
+func (t *Tiers) Division(s Standing) (Division, bool) {
+	if s.Division == Unplaced {
+		return Division{}, false
+	}
+	return t.divisions[s.Division], true
+}
+
+// Record returns the standing after a game played at the time, which
+// resulted in the rating.
+func (t *Tiers) Record(s Standing, r *trueskill.Rating, at time.Time) Standing {
+	s.Games++
+	s.LastPlayed = at
+	return t.move(s, r, t.score(r))
+}
+
+// Refresh returns the standing at the time without a game, which may have
+// decayed.
+func (t *Tiers) Refresh(s Standing, r *trueskill.Rating, now time.Time) Standing {
+	return t.move(s, r, t.decayed(s, r, now))
+}
+
+func (t *Tiers) decayed(s Standing, r *trueskill.Rating, now time.Time) float64 {
+	score := t.score(r)
+	if t.perDay <= 0 || s.Division == Unplaced || !t.divisions[s.Division].Decays {
+		return score
+	}
+
+	idle := now.Sub(s.LastPlayed) - t.grace
+	if idle <= 0 {
+		return score
+	}
+	return score - t.perDay*idle.Hours()/24
+}
+
+func (t *Tiers) move(s Standing, r *trueskill.Rating, score float64) Standing {
+	if s.Division == Unplaced {
+		if s.Games < t.minGames && (t.maxSigma <= 0 || r.Sigma > t.maxSigma) {
+			return s
+		}
+		s.Division = t.find(score)
+		return s
+	}
+
+	for s.Division+1 < len(t.divisions) && score >= t.divisions[s.Division+1].Min+t.promotion {
+		s.Division++
+	}
+	for s.Division > 0 && score < t.divisions[s.Division].Min-t.demotion {
+		s.Division--
+	}
+	return s
+}
+
+// find returns the division of the score without buffers.
+func (t *Tiers) find(score float64) int {
+	d := 0
+	for d+1 < len(t.divisions) && score >= t.divisions[d+1].Min {
+		d++
+	}
+	return d
+}
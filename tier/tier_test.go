@@ -0,0 +1,72 @@
+package tier_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/tier"
+)
+
+func TestTiers(t *testing.T) {
+	// Scores are the means of ratings.
+	env := trueskill.NewTrueSkill(trueskill.MU(0), trueskill.Sigma(1))
+	tiers := tier.New(env,
+		tier.Divisions(
+			tier.Division{Name: "Low", Min: 0},
+			tier.Division{Name: "Mid", Min: 10},
+			tier.Division{Name: "High", Min: 20, Decays: true},
+		),
+		tier.Placement(3, 0),
+		tier.Buffer(2, 2),
+		tier.Decay(24*time.Hour, 1),
+	)
+	rating := func(score float64) *trueskill.Rating {
+		return trueskill.NewRating(score, 0, 1)
+	}
+	day := time.Unix(0, 0)
+
+	s := tier.NewStanding()
+	for i := 0; i < 2; i++ {
+		s = tiers.Record(s, rating(15), day)
+		if _, ok := tiers.Division(s); ok {
+			t.Fatalf("placed after %d games", s.Games)
+		}
+	}
+
+	for _, step := range []struct {
+		score float64
+		want  string
+	}{
+		{15, "Mid"},  // placed by the score without buffers.
+		{21, "Mid"},  // within the promotion buffer.
+		{22, "High"}, // promoted.
+		{19, "High"}, // within the demotion buffer.
+		{17, "Mid"},  // demoted.
+		{40, "High"}, // promoted over the buffer at once.
+	} {
+		s = tiers.Record(s, rating(step.score), day)
+		if d, _ := tiers.Division(s); d.Name != step.want {
+			t.Errorf("score %v: got %s, want %s", step.score, d.Name, step.want)
+		}
+	}
+
+	// The score 20 decays below the demotion buffer 3 days after the grace.
+	s = tiers.Record(s, rating(20), day)
+	if d, _ := tiers.Division(tiers.Refresh(s, rating(20), day.Add(72*time.Hour))); d.Name != "High" {
+		t.Errorf("got %s before decay", d.Name)
+	}
+	if d, _ := tiers.Division(tiers.Refresh(s, rating(20), day.Add(96*time.Hour+time.Minute))); d.Name != "Mid" {
+		t.Errorf("got %s after decay", d.Name)
+	}
+}
+
+func TestPlacementBySigma(t *testing.T) {
+	env := trueskill.NewTrueSkill()
+	tiers := tier.New(env, tier.Placement(10, 3))
+
+	s := tiers.Record(tier.NewStanding(), trueskill.NewRating(30, 2, 1), time.Unix(0, 0))
+	if d, ok := tiers.Division(s); !ok || d.Name != "Platinum" {
+		t.Errorf("got %+v, %v", d, ok)
+	}
+}
@@ -0,0 +1,368 @@
+// Package timeline rates matches in the order they were played, even if
+// some results arrive after later matches have been rated, and undoes
+// voided matches.
+package timeline
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gami/go-trueskill"
+)
+
+var (
+	// ErrTooLate is returned for a match older than the window.
+	ErrTooLate = errors.New("match is older than the window")
+	// ErrNotFound is returned for a match which is not within the history.
+	ErrNotFound = errors.New("match not found in the history")
+)
+
+// Change is the change of the current rating of a player.
+type Change struct {
+	Before *trueskill.Rating // nil for a new player.
+	After  *trueskill.Rating // nil for a player removed with their only matches.
+}
+
+// Report is the result of adding a match.
+type Report struct {
+	Rerated []string          // IDs of the later matches rated again, in time order.
+	Changes map[string]Change // changes of current ratings by player ID.
+}
+
+// Correction is an audit record of a voided match.
+type Correction struct {
+	MatchID string
+	Reason  string
+	At      time.Time
+	Report
+}
+
+// entry is a rated match with the ratings of its players around it.
+type entry struct {
+	match   *trueskill.Match
+	before  map[string]*trueskill.Rating
+	after   map[string]*trueskill.Rating
+	created map[string]bool // players first rated by the match.
+}
+
+func (e *entry) has(id string) bool {
+	_, ok := e.before[id]
+	return ok
+}
+
+// Updater keeps current ratings and the matches within the history. Matches
+// within the window are rated again when a match played before them
+// arrives, and any match within the history can be voided.
+type Updater struct {
+	rater   trueskill.Rater
+	window  time.Duration
+	history time.Duration
+	now     func() time.Time
+	ratings map[string]*trueskill.Rating
+	entries []*entry // sorted by the time of the match.
+
+	corrections []*Correction
+}
+
+type option func(*Updater)
+
+func New(rater trueskill.Rater, options ...option) *Updater {
+	u := &Updater{
+		rater:   rater,
+		window:  10 * time.Minute,
+		history: 7 * 24 * time.Hour,
+		now:     time.Now,
+		ratings: make(map[string]*trueskill.Rating),
+	}
+
+	for _, opt := range options {
+		opt(u)
+	}
+
+	return u
+}
+
+// Window is how much older than the latest match a match may be. The
+// default is 10 minutes.
+func Window(d time.Duration) option {
+	return func(u *Updater) {
+		u.window = d
+	}
+}
+
+// History is how much older than the latest match a match may be to be
+// voided. Matches older than both the history and the window are forgotten,
+// and 0 keeps every match. The default is 7 days.
+func History(d time.Duration) option {
+	return func(u *Updater) {
+		u.history = d
+	}
+}
+
+// WithClock sets the function which tells the time of corrections.
+func WithClock(now func() time.Time) option {
+	return func(u *Updater) {
+		u.now = now
+	}
+}
+
+// WithRatings sets the current ratings to start from.
+func WithRatings(ratings map[string]*trueskill.Rating) option {
+	return func(u *Updater) {
+		for id, r := range ratings {
+			u.ratings[id] = r
+		}
+	}
+}
+
+// Rating returns the current rating of the player.
+func (u *Updater) Rating(id string) (*trueskill.Rating, bool) {
+	r, ok := u.ratings[id]
+	return r, ok
+}
+
+// Ratings returns the current ratings by player ID.
+func (u *Updater) Ratings() map[string]*trueskill.Rating {
+	ratings := make(map[string]*trueskill.Rating, len(u.ratings))
+	for id, r := range u.ratings {
+		ratings[id] = r
+	}
+	return ratings
+}
+
+// Add rates the match at its time. If later matches share players with it,
+// those players are rolled back to their ratings before the match, and the
+// later matches of them are rated again in order. A later match brings its
+// other players into the replay too.
+func (u *Updater) Add(m *trueskill.Match) (*Report, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	if len(u.entries) > 0 {
+		latest := u.entries[len(u.entries)-1].match.Time
+		if m.Time.Before(latest.Add(-u.window)) {
+			return nil, fmt.Errorf("match %q at %v: %w", m.ID, m.Time, ErrTooLate)
+		}
+	}
+	for _, e := range u.entries {
+		if e.match.ID == m.ID {
+			return nil, fmt.Errorf("match %q is already added", m.ID)
+		}
+	}
+
+	at := sort.Search(len(u.entries), func(i int) bool {
+		return u.entries[i].match.Time.After(m.Time)
+	})
+
+	// working has the ratings of the replayed players at the current point.
+	working := make(map[string]*trueskill.Rating)
+	for _, id := range m.Players() {
+		if r, ok := u.ratingAt(id, at); ok {
+			working[id] = r
+		}
+	}
+
+	inserted, err := u.rate(m, working)
+	if err != nil {
+		return nil, err
+	}
+	affected := make(map[string]bool)
+	for _, id := range m.Players() {
+		affected[id] = true
+	}
+
+	rerated, report, err := u.replay(at, working, affected)
+	if err != nil {
+		return nil, err
+	}
+
+	// Entries change only after every match is rated again.
+	for i, e := range rerated {
+		u.entries[i] = e
+	}
+	u.entries = append(u.entries, nil)
+	copy(u.entries[at+1:], u.entries[at:])
+	u.entries[at] = inserted
+
+	u.commit(report, working, affected)
+	u.prune()
+
+	return report, nil
+}
+
+// Void removes the match from the history. Its players are rolled back to
+// their ratings before it, and their later matches are rated again like Add.
+// Players with no other match are removed. The correction is kept as an
+// audit record.
+func (u *Updater) Void(matchID string, reason string) (*Correction, error) {
+	at := -1
+	for i, e := range u.entries {
+		if e.match.ID == matchID {
+			at = i
+			break
+		}
+	}
+	if at < 0 {
+		return nil, fmt.Errorf("match %q: %w", matchID, ErrNotFound)
+	}
+
+	voided := u.entries[at]
+	working := make(map[string]*trueskill.Rating)
+	affected := make(map[string]bool)
+	for id, r := range voided.before {
+		if !voided.created[id] {
+			working[id] = r
+		}
+		affected[id] = true
+	}
+
+	rerated, report, err := u.replay(at+1, working, affected)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, e := range rerated {
+		u.entries[i] = e
+	}
+	u.entries = append(u.entries[:at], u.entries[at+1:]...)
+
+	u.commit(report, working, affected)
+
+	c := &Correction{
+		MatchID: matchID,
+		Reason:  reason,
+		At:      u.now(),
+		Report:  *report,
+	}
+	u.corrections = append(u.corrections, c)
+
+	return c, nil
+}
+
+// Corrections returns the audit records of voided matches in order.
+func (u *Updater) Corrections() []*Correction {
+	return append([]*Correction(nil), u.corrections...)
+}
+
+// replay rates again the entries from the i-th which involve the affected
+// players, starting from the working ratings. A replayed entry adds its
+// other players to the affected. It returns the new entries by index and
+// leaves the entries of the updater as they are.
+func (u *Updater) replay(i int, working map[string]*trueskill.Rating, affected map[string]bool) (map[int]*entry, *Report, error) {
+	report := &Report{
+		Rerated: make([]string, 0),
+		Changes: make(map[string]Change),
+	}
+
+	rerated := make(map[int]*entry)
+	for ; i < len(u.entries); i++ {
+		e := u.entries[i]
+
+		involved := false
+		for id := range e.before {
+			if affected[id] {
+				involved = true
+				break
+			}
+		}
+		if !involved {
+			continue
+		}
+
+		for id, r := range e.before {
+			if !affected[id] {
+				affected[id] = true
+				working[id] = r
+			}
+		}
+
+		again, err := u.rate(e.match, working)
+		if err != nil {
+			return nil, nil, err
+		}
+		rerated[i] = again
+		report.Rerated = append(report.Rerated, e.match.ID)
+	}
+
+	return rerated, report, nil
+}
+
+// commit sets the current ratings of the affected players to the working
+// ratings and records the changes in the report. Affected players missing
+// from the working ratings are removed.
+func (u *Updater) commit(report *Report, working map[string]*trueskill.Rating, affected map[string]bool) {
+	for id := range affected {
+		before := u.ratings[id]
+		after, ok := working[id]
+		if ok {
+			u.ratings[id] = after
+		} else {
+			delete(u.ratings, id)
+		}
+		report.Changes[id] = Change{Before: before, After: after}
+	}
+}
+
+// ratingAt returns the rating of the player just before the i-th entry.
+func (u *Updater) ratingAt(id string, i int) (*trueskill.Rating, bool) {
+	for ; i < len(u.entries); i++ {
+		if u.entries[i].has(id) {
+			return u.entries[i].before[id], true
+		}
+	}
+	r, ok := u.ratings[id]
+	return r, ok
+}
+
+// rate rates the match from the working ratings and updates them.
+func (u *Updater) rate(m *trueskill.Match, working map[string]*trueskill.Rating) (*entry, error) {
+	e := &entry{
+		match:   m,
+		before:  make(map[string]*trueskill.Rating),
+		created: make(map[string]bool),
+	}
+	for _, id := range m.Players() {
+		if _, ok := working[id]; !ok {
+			e.created[id] = true
+		}
+	}
+
+	groups := trueskill.Groups(u.rater, m, working)
+	for i, team := range m.Teams {
+		for j, id := range team {
+			e.before[id] = groups[i][j]
+		}
+	}
+
+	after, err := u.rater.RateMatch(m, working)
+	if err != nil {
+		return nil, fmt.Errorf("match %q: %w", m.ID, err)
+	}
+	e.after = after
+
+	for id, r := range after {
+		working[id] = r
+	}
+
+	return e, nil
+}
+
+// prune forgets the matches older than both the window and the history.
+func (u *Updater) prune() {
+	if len(u.entries) == 0 || u.history == 0 {
+		return
+	}
+	keep := u.window
+	if u.history > keep {
+		keep = u.history
+	}
+	oldest := u.entries[len(u.entries)-1].match.Time.Add(-keep)
+
+	i := sort.Search(len(u.entries), func(i int) bool {
+		return !u.entries[i].match.Time.Before(oldest)
+	})
+	u.entries = u.entries[i:]
+}
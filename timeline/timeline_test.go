@@ -0,0 +1,149 @@
+package timeline_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/timeline"
+)
+
+func match(id string, minute int, teams ...[]string) *trueskill.Match {
+	return &trueskill.Match{ID: id, Time: time.Unix(int64(minute*60), 0), Teams: teams}
+}
+
+func TestAddLateMatch(t *testing.T) {
+	env := trueskill.NewTrueSkill()
+	u := timeline.New(env, timeline.Window(5*time.Minute))
+
+	m1 := match("m1", 1, []string{"a"}, []string{"b"})
+	m2 := match("m2", 3, []string{"b"}, []string{"c"})
+	m3 := match("m3", 4, []string{"d"}, []string{"e"})
+	late := match("late", 2, []string{"c"}, []string{"a"})
+
+	for _, m := range []*trueskill.Match{m1, m2, m3} {
+		if _, err := u.Add(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := u.Add(late)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(report.Rerated, []string{"m2"}) {
+		t.Errorf("got rerated %v", report.Rerated)
+	}
+	if _, ok := report.Changes["d"]; ok || len(report.Changes) != 3 {
+		t.Errorf("got changes %v", report.Changes)
+	}
+
+	// The result equals rating the matches in time order.
+	want := make(map[string]*trueskill.Rating)
+	for _, m := range []*trueskill.Match{m1, late, m2, m3} {
+		rated, err := env.RateMatch(m, want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for id, r := range rated {
+			want[id] = r
+		}
+	}
+	if got := u.Ratings(); !reflect.DeepEqual(got, want) {
+		for id := range want {
+			t.Errorf("%s: got %+v, want %+v", id, got[id], want[id])
+		}
+	}
+
+	if _, err := u.Add(match("old", -2, []string{"a"}, []string{"b"})); !errors.Is(err, timeline.ErrTooLate) {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestVoid(t *testing.T) {
+	env := trueskill.NewTrueSkill()
+	now := time.Unix(1000, 0)
+	u := timeline.New(env, timeline.WithClock(func() time.Time { return now }))
+
+	m1 := match("m1", 1, []string{"a"}, []string{"b"})
+	cheated := match("cheated", 2, []string{"c"}, []string{"a"})
+	m2 := match("m2", 3, []string{"a"}, []string{"d"})
+	m3 := match("m3", 4, []string{"e"}, []string{"f"})
+
+	for _, m := range []*trueskill.Match{m1, cheated, m2, m3} {
+		if _, err := u.Add(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := u.Void("cheated", "win trading")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Reason != "win trading" || !c.At.Equal(now) || !reflect.DeepEqual(c.Rerated, []string{"m2"}) {
+		t.Errorf("got %+v", c)
+	}
+	if _, ok := c.Changes["e"]; ok || c.Changes["a"].Before == nil {
+		t.Errorf("got changes %v", c.Changes)
+	}
+
+	// The result equals rating the matches without the voided one.
+	want := make(map[string]*trueskill.Rating)
+	for _, m := range []*trueskill.Match{m1, m2, m3} {
+		rated, err := env.RateMatch(m, want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for id, r := range rated {
+			want[id] = r
+		}
+	}
+	got := u.Ratings()
+	for _, id := range []string{"a", "b", "d", "e", "f"} {
+		if !reflect.DeepEqual(got[id], want[id]) {
+			t.Errorf("%s: got %+v, want %+v", id, got[id], want[id])
+		}
+	}
+	if _, ok := u.Rating("c"); ok || c.Changes["c"].Before == nil || c.Changes["c"].After != nil {
+		t.Errorf("c had no other match and must be removed, got %+v", c.Changes["c"])
+	}
+
+	if _, err := u.Void("cheated", ""); !errors.Is(err, timeline.ErrNotFound) {
+		t.Errorf("got %v", err)
+	}
+	if len(u.Corrections()) != 1 {
+		t.Errorf("got %d corrections", len(u.Corrections()))
+	}
+}
+
+func TestVoidBeyondWindow(t *testing.T) {
+	u := timeline.New(trueskill.NewTrueSkill(), timeline.Window(time.Minute), timeline.History(time.Hour))
+
+	for _, m := range []*trueskill.Match{
+		match("m1", 1, []string{"a"}, []string{"b"}),
+		match("m2", 30, []string{"a"}, []string{"c"}),
+		match("m3", 90, []string{"d"}, []string{"e"}),
+	} {
+		if _, err := u.Add(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// m2 is too old to arrive late, but not to be voided.
+	if _, err := u.Add(match("late", 29, []string{"b"}, []string{"c"})); !errors.Is(err, timeline.ErrTooLate) {
+		t.Errorf("got %v", err)
+	}
+	c, err := u.Void("m2", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Changes["a"]; !ok {
+		t.Errorf("got changes %v", c.Changes)
+	}
+
+	if _, err := u.Void("m1", ""); !errors.Is(err, timeline.ErrNotFound) {
+		t.Errorf("got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package trueskill
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gami/go-trueskill/factorgraph"
+	"github.com/gami/go-trueskill/mathmatics"
+)
+
+// Distribution is a Gaussian described by its mean and standard deviation.
+type Distribution struct {
+	Mu    float64 `json:"mu"`
+	Sigma float64 `json:"sigma"`
+}
+
+func newDistribution(g *mathmatics.Gaussian) Distribution {
+	return Distribution{
+		Mu:    g.Mu(),
+		Sigma: g.Sigma(),
+	}
+}
+
+// Trace is a breakdown of a rating update recorded by Explain.
+type Trace struct {
+	Iterations   int                `json:"iterations"` // sweeps, or single updates with ResidualSchedule.
+	Converged    bool               `json:"converged"`  // false if the iterations hit the limit before the updates became small.
+	Participants []ParticipantTrace `json:"participants"`
+	Teams        []TeamTrace        `json:"teams"`
+	Diffs        []DiffTrace        `json:"diffs"`
+}
+
+// ParticipantTrace is the breakdown of a single rating.
+type ParticipantTrace struct {
+	Team        int          `json:"team"`
+	Member      int          `json:"member"`
+	Prior       Distribution `json:"prior"`        // the message from the prior factor, which adds tau to the sigma of the rating.
+	PerfMessage Distribution `json:"perf_message"` // the message from the performance layer.
+	Posterior   Distribution `json:"posterior"`
+}
+
+// TeamTrace is the posterior of a team performance.
+type TeamTrace struct {
+	Team        int          `json:"team"`
+	Performance Distribution `json:"performance"`
+}
+
+// DiffTrace is the posterior of a performance difference between two teams.
+type DiffTrace struct {
+	Winner int          `json:"winner"`
+	Loser  int          `json:"loser"`
+	Draw   bool         `json:"draw"`
+	Diff   Distribution `json:"diff"`
+	V      float64      `json:"v"`
+	W      float64      `json:"w"`
+}
+
+func (t *Trace) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "iterations: %d converged: %v\n", t.Iterations, t.Converged)
+	for _, p := range t.Participants {
+		fmt.Fprintf(&b, "team=%d member=%d prior=%s perf=%s posterior=%s\n",
+			p.Team, p.Member, p.Prior, p.PerfMessage, p.Posterior)
+	}
+	for _, tm := range t.Teams {
+		fmt.Fprintf(&b, "team=%d performance=%s\n", tm.Team, tm.Performance)
+	}
+	for _, d := range t.Diffs {
+		fmt.Fprintf(&b, "diff=%d-%d draw=%v diff=%s v=%f w=%f\n", d.Winner, d.Loser, d.Draw, d.Diff, d.V, d.W)
+	}
+
+	return b.String()
+}
+
+func (d Distribution) String() string {
+	return fmt.Sprintf("N(%f, %f)", d.Mu, d.Sigma)
+}
+
+func (t *Trace) record(
+	ratingLayer []*factorgraph.PriorFactor,
+	flattenRatings []*Rating,
+	perfLayer []factorgraph.Factor,
+	teamSizes []int,
+	teamPerfVars []*factorgraph.Variable,
+	teamDiffVars []*factorgraph.Variable,
+	truncLayer []*factorgraph.TruncateFactor,
+	constraints []Constraint,
+) {
+	t.Participants = make([]ParticipantTrace, 0, len(flattenRatings))
+	team := 0
+	member := 0
+	for i := range flattenRatings {
+		for i >= teamSizes[team] {
+			team++
+			member = 0
+		}
+
+		v := ratingLayer[i].Var()
+		t.Participants = append(t.Participants, ParticipantTrace{
+			Team:        team,
+			Member:      member,
+			Prior:       newDistribution(v.Message(ratingLayer[i])),
+			PerfMessage: newDistribution(v.Message(perfLayer[i])),
+			Posterior:   newDistribution(v.Gaussian),
+		})
+		member++
+	}
+
+	t.Teams = make([]TeamTrace, 0, len(teamPerfVars))
+	for i, v := range teamPerfVars {
+		t.Teams = append(t.Teams, TeamTrace{
+			Team:        i,
+			Performance: newDistribution(v.Gaussian),
+		})
+	}
+
+	t.Diffs = make([]DiffTrace, 0, len(teamDiffVars))
+	for i, v := range teamDiffVars {
+		vv, w := truncLayer[i].VW()
+		t.Diffs = append(t.Diffs, DiffTrace{
+			Winner: constraints[i].Winner,
+			Loser:  constraints[i].Loser,
+			Draw:   constraints[i].Draw,
+			Diff:   newDistribution(v.Gaussian),
+			V:      vv,
+			W:      w,
+		})
+	}
+}
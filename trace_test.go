@@ -0,0 +1,135 @@
+package trueskill_test
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gami/go-trueskill"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-5
+}
+
+func TestExplain1v1(t *testing.T) {
+	env := trueskill.NewTrueSkill()
+	var trace trueskill.Trace
+	rated, err := env.Rate([][]*trueskill.Rating{{env.CreateRating()}, {env.CreateRating()}}, trueskill.Explain(&trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The prior factor adds tau to the sigma of the rating.
+	sigma := math.Sqrt(math.Pow(25.0/3, 2) + math.Pow(25.0/300, 2))
+	for i, p := range trace.Participants {
+		if p.Team != i || p.Member != 0 || !near(p.Prior.Mu, 25) || !near(p.Prior.Sigma, sigma) {
+			t.Errorf("participant %d: got %+v", i, p)
+		}
+
+		// The posterior is the product of the prior and the performance message.
+		pi := 1/(p.Prior.Sigma*p.Prior.Sigma) + 1/(p.PerfMessage.Sigma*p.PerfMessage.Sigma)
+		tau := p.Prior.Mu/(p.Prior.Sigma*p.Prior.Sigma) + p.PerfMessage.Mu/(p.PerfMessage.Sigma*p.PerfMessage.Sigma)
+		if !near(p.Posterior.Sigma, 1/math.Sqrt(pi)) || !near(p.Posterior.Mu, tau/pi) {
+			t.Errorf("participant %d: posterior %+v is not the product of the messages", i, p.Posterior)
+		}
+		if !near(p.Posterior.Mu, rated[i][0].Mu) || !near(p.Posterior.Sigma, rated[i][0].Sigma) {
+			t.Errorf("participant %d: got %+v, rated %+v", i, p.Posterior, rated[i][0])
+		}
+	}
+	if trace.Participants[0].PerfMessage.Mu <= trace.Participants[1].PerfMessage.Mu {
+		t.Errorf("the winner must get the higher performance message: %+v", trace.Participants)
+	}
+
+	if len(trace.Teams) != 2 || trace.Teams[0].Performance.Mu <= trace.Teams[1].Performance.Mu {
+		t.Errorf("got teams %+v", trace.Teams)
+	}
+
+	// The difference of N(0, c) truncated at the draw margin.
+	c := math.Sqrt(2*env.Beta()*env.Beta() + 2*sigma*sigma)
+	x := -env.DrawMargin(2) / c
+	pdf := math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+	cdf := (1 + math.Erf(x/math.Sqrt2)) / 2
+	v := pdf / cdf
+	w := v * (v + x)
+	if len(trace.Diffs) != 1 {
+		t.Fatalf("got diffs %+v", trace.Diffs)
+	}
+	d := trace.Diffs[0]
+	if d.Winner != 0 || d.Loser != 1 || d.Draw {
+		t.Errorf("got diff %+v", d)
+	}
+	if !near(d.V, v) || !near(d.W, w) {
+		t.Errorf("got v=%v w=%v, want v=%v w=%v", d.V, d.W, v, w)
+	}
+	if !near(d.Diff.Mu, c*v) || !near(d.Diff.Sigma, c*math.Sqrt(1-w)) {
+		t.Errorf("got diff %+v, want N(%v, %v)", d.Diff, c*v, c*math.Sqrt(1-w))
+	}
+
+	s := trace.String()
+	for _, want := range []string{
+		"iterations: ",
+		"team=0 member=0 prior=N(25.000000, 8.333750)",
+		"team=1 performance=N(",
+		"diff=0-1 draw=false diff=N(",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("%q is missing in\n%s", want, s)
+		}
+	}
+
+	data, err := json.Marshal(&trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got trueskill.Trace
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, trace) {
+		t.Errorf("got %+v, want %+v", got, trace)
+	}
+}
+
+func TestExplain3Teams(t *testing.T) {
+	env := trueskill.NewTrueSkill()
+	groups := [][]*trueskill.Rating{
+		{env.CreateRating()},
+		{env.CreateRating(), env.CreateRating()},
+		{env.CreateRating()},
+	}
+	var trace trueskill.Trace
+	rated, err := env.Rate(groups, trueskill.Explain(&trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(trace.Participants) != 4 || len(trace.Teams) != 3 || len(trace.Diffs) != 2 || trace.Iterations < 1 {
+		t.Fatalf("got %+v", trace)
+	}
+	p := trace.Participants[2]
+	if p.Team != 1 || p.Member != 1 || !near(p.Posterior.Mu, rated[1][1].Mu) || !near(p.Posterior.Sigma, rated[1][1].Sigma) {
+		t.Errorf("got %+v, rated %+v", p, rated[1][1])
+	}
+	for i, d := range trace.Diffs {
+		if d.Winner != i || d.Loser != i+1 || d.Diff.Mu <= 0 || d.V <= 0 || d.W <= 0 || d.W >= 1 {
+			t.Errorf("diff %d: got %+v", i, d)
+		}
+	}
+}
+
+func TestExplainMatch(t *testing.T) {
+	env := trueskill.NewTrueSkill()
+	m := &trueskill.Match{ID: "m", Teams: [][]string{{"a"}, {"b", "c"}}}
+
+	var trace trueskill.Trace
+	rated, err := env.RateMatchWith(m, nil, trueskill.Explain(&trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trace.Participants) != 3 || !near(trace.Participants[2].Posterior.Mu, rated["c"].Mu) {
+		t.Errorf("got %+v, rated %+v", trace.Participants, rated["c"])
+	}
+}
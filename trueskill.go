@@ -22,11 +22,13 @@ const (
 
 // TrueSkill represents envirionment of rating
 type TrueSkill struct {
-	mu              float64 // the initial mean of ratings.
-	sigma           float64 // the initial standard deviation of ratings. The recommended value is a third of mu.
-	beta            float64 // the distance which guarantees about 76% chance of winning. The recommended value is a half of sigma.
-	tau             float64 // the dynamic factor which restrains a fixation of rating. The recommended value is sigma per cent.
-	drawProbability float64 // the draw probability between two teams. It can be a float or function which returns a float by the given two rating (team performance) arguments and the beta value. If it is a float, the game has fixed draw probability. Otherwise, the draw probability will be decided dynamically per each match.
+	mu              float64  // the initial mean of ratings.
+	sigma           float64  // the initial standard deviation of ratings. The recommended value is a third of mu.
+	beta            float64  // the distance which guarantees about 76% chance of winning. The recommended value is a half of sigma.
+	tau             float64  // the dynamic factor which restrains a fixation of rating. The recommended value is sigma per cent.
+	drawProbability float64  // the draw probability between two teams. It can be a float or function which returns a float by the given two rating (team performance) arguments and the beta value. If it is a float, the game has fixed draw probability. Otherwise, the draw probability will be decided dynamically per each match.
+	damping         float64  // the weight of the previous message kept on each update of the truncate layer. 0 disables damping.
+	schedule        Schedule // the order of message passing within the team difference layer.
 }
 
 type option func(*TrueSkill)
@@ -86,71 +88,203 @@ func DrawProbability(v float64) option {
 	}
 }
 
+// Damping keeps the given weight (0 <= v < 1) of the previous message on
+// each update of the truncate layer. It restrains oscillation of matches
+// among many teams. Rating fails for a weight out of the range.
+func Damping(v float64) option {
+	return func(s *TrueSkill) {
+		s.damping = v
+	}
+}
+
+// UseSchedule sets the order of message passing within the team difference
+// layer. The default is SweepSchedule.
+func UseSchedule(v Schedule) option {
+	return func(s *TrueSkill) {
+		s.schedule = v
+	}
+}
+
+type rateOption func(*rateConfig)
+
+type rateConfig struct {
+	trace   *Trace
+	inspect func(iteration int, g *factorgraph.Graph)
+}
+
+// Explain records the breakdown of the rating update into t.
+func Explain(t *Trace) rateOption {
+	return func(c *rateConfig) {
+		c.trace = t
+	}
+}
+
+// InspectGraph calls fn with the factor graph after each schedule iteration
+// and once more after the final update, so that convergence can be exported.
+func InspectGraph(fn func(iteration int, g *factorgraph.Graph)) rateOption {
+	return func(c *rateConfig) {
+		c.inspect = fn
+	}
+}
+
+func newRateConfig(opts []rateOption) *rateConfig {
+	c := &rateConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
 func (s *TrueSkill) CreateRating() *Rating {
 	return NewRating(s.mu, s.sigma, 1)
 }
 
+// Beta returns the standard deviation of a performance around the skill.
+func (s *TrueSkill) Beta() float64 {
+	return s.beta
+}
+
+// Params returns the parameters of the environment by name.
+func (s *TrueSkill) Params() map[string]float64 {
+	return map[string]float64{
+		"mu":               s.mu,
+		"sigma":            s.sigma,
+		"beta":             s.beta,
+		"tau":              s.tau,
+		"draw_probability": s.drawProbability,
+		"damping":          s.damping,
+	}
+}
+
+// DrawMargin returns the margin of team performances within which a match
+// among size players is a draw.
+func (s *TrueSkill) DrawMargin(size int) float64 {
+	return s.calcDrawMargin(size)
+}
+
 // Rate recalculates ratings by the ranking table:
-func (s *TrueSkill) Rate(ratingGroups [][]*Rating) ([][]*Rating, error) {
+func (s *TrueSkill) Rate(ratingGroups [][]*Rating, opts ...rateOption) ([][]*Rating, error) {
 	if err := s.validateRatingGroup(ratingGroups); err != nil {
 		return nil, err
 	}
 
-	flattenRatings := make([]*Rating, 0)
-	sortedRanks := make([]int, 0)
-	rank := 0
+	return s.rate(ratingGroups, chainConstraints(len(ratingGroups)), newRateConfig(opts))
+}
+
+// rate recalculates ratings by the outcomes between the groups.
+func (s *TrueSkill) rate(ratingGroups [][]*Rating, constraints []Constraint, cfg *rateConfig) ([][]*Rating, error) {
+	if err := s.validateOptions(); err != nil {
+		return nil, err
+	}
+	g := s.buildRateGraph(ratingGroups)
+
+	if err := s.runSchedule(g, constraints, cfg); err != nil {
+		return nil, err
+	}
+
+	return g.ratings(), nil
+}
+
+// rateGraph is the part of the factor graph which does not depend on the
+// outcome, from the ratings down to the team performances.
+type rateGraph struct {
+	ratingGroups   [][]*Rating
+	flattenRatings []*Rating
+	flattenWeights []float64
+	teamSizes      []int
+	ratingVars     []*factorgraph.Variable
+	perfVars       []*factorgraph.Variable
+	teamPerfVars   []*factorgraph.Variable
+	ratingLayer    []*factorgraph.PriorFactor
+	perfLayer      []factorgraph.Factor
+	teamPerfLayer  []*factorgraph.SumFactor
+}
+
+// buildRateGraph builds the layers down to the team performances and sends
+// messages down through them.
+func (s *TrueSkill) buildRateGraph(ratingGroups [][]*Rating) *rateGraph {
+	g := &rateGraph{
+		ratingGroups:   ratingGroups,
+		flattenRatings: make([]*Rating, 0),
+		teamSizes:      teamSizes(ratingGroups),
+	}
+
 	for _, rg := range ratingGroups {
-		for _, r := range rg {
-			flattenRatings = append(flattenRatings, r)
-			sortedRanks = append(sortedRanks, rank)
-		}
-		rank++
+		g.flattenRatings = append(g.flattenRatings, rg...)
 	}
 
-	ratingVars := make([]*factorgraph.Variable, 0, len(flattenRatings))
-	perfVars := make([]*factorgraph.Variable, 0, len(flattenRatings))
-	flattenWeights := make([]float64, 0, len(flattenRatings))
-	for _, r := range flattenRatings {
-		ratingVars = append(ratingVars, factorgraph.NewVariable(mathmatics.NewGaussian(0, 0)))
-		perfVars = append(perfVars, factorgraph.NewVariable(mathmatics.NewGaussian(0, 0)))
-		flattenWeights = append(flattenWeights, r.Weight)
+	g.ratingVars = make([]*factorgraph.Variable, 0, len(g.flattenRatings))
+	g.perfVars = make([]*factorgraph.Variable, 0, len(g.flattenRatings))
+	g.flattenWeights = make([]float64, 0, len(g.flattenRatings))
+	for _, r := range g.flattenRatings {
+		g.ratingVars = append(g.ratingVars, factorgraph.NewVariable(mathmatics.NewGaussian(0, 0)))
+		g.perfVars = append(g.perfVars, factorgraph.NewVariable(mathmatics.NewGaussian(0, 0)))
+		g.flattenWeights = append(g.flattenWeights, r.Weight)
 	}
 
-	teamPerfVars := make([]*factorgraph.Variable, 0, len(ratingGroups))
+	g.teamPerfVars = make([]*factorgraph.Variable, 0, len(ratingGroups))
 	for i := 0; i < len(ratingGroups); i++ {
-		teamPerfVars = append(teamPerfVars, factorgraph.NewVariable(mathmatics.NewGaussian(0, 0)))
+		g.teamPerfVars = append(g.teamPerfVars, factorgraph.NewVariable(mathmatics.NewGaussian(0, 0)))
 	}
 
-	teamDiffVars := make([]*factorgraph.Variable, 0, len(ratingGroups)-1)
-	for i := 0; i < len(ratingGroups)-1; i++ {
-		teamDiffVars = append(teamDiffVars, factorgraph.NewVariable(mathmatics.NewGaussian(0, 0)))
+	g.ratingLayer = s.buildRatingLayer(g.ratingVars, g.flattenRatings)
+	g.perfLayer = s.buildPerfLayer(g.ratingVars, g.perfVars)
+	g.teamPerfLayer = s.buildTeamPerfLayer(
+		g.teamPerfVars,
+		g.perfVars,
+		g.teamSizes,
+		g.flattenWeights,
+	)
+
+	for _, f := range g.ratingLayer {
+		f.Down()
+	}
+	for _, f := range g.perfLayer {
+		f.Down()
+	}
+	for _, f := range g.teamPerfLayer {
+		f.Down()
 	}
 
-	teamSizes := teamSizes(ratingGroups)
+	return g
+}
 
-	layers, err := s.runSchedule(
-		ratingVars,
-		flattenRatings,
-		perfVars,
-		teamPerfVars,
-		teamSizes,
-		flattenWeights,
-		teamDiffVars,
-		sortedRanks,
-		ratingGroups,
-	)
-	if err != nil {
-		return nil, err
+// variables returns the variables of the graph.
+func (g *rateGraph) variables() []*factorgraph.Variable {
+	vars := make([]*factorgraph.Variable, 0, len(g.ratingVars)+len(g.perfVars)+len(g.teamPerfVars))
+	vars = append(vars, g.ratingVars...)
+	vars = append(vars, g.perfVars...)
+	vars = append(vars, g.teamPerfVars...)
+	return vars
+}
+
+// save returns the states of the variables to restore the graph after an
+// outcome is solved on it.
+func (g *rateGraph) save() []*factorgraph.State {
+	vars := g.variables()
+	states := make([]*factorgraph.State, 0, len(vars))
+	for _, v := range vars {
+		states = append(states, v.Save())
 	}
+	return states
+}
 
-	transformedGroups := make([][]*Rating, 0, len(teamSizes))
+func (g *rateGraph) restore(states []*factorgraph.State) {
+	for i, v := range g.variables() {
+		v.Restore(states[i])
+	}
+}
+
+// ratings returns the ratings of the groups at the rating variables.
+func (g *rateGraph) ratings() [][]*Rating {
+	transformedGroups := make([][]*Rating, 0, len(g.teamSizes))
 
 	trimmed := []int{0}
-	trimmed = append(trimmed, teamSizes[0:len(teamSizes)-1]...)
+	trimmed = append(trimmed, g.teamSizes[0:len(g.teamSizes)-1]...)
 
-	for i := 0; i < len(teamSizes); i++ {
+	for i := 0; i < len(g.teamSizes); i++ {
 		group := make([]*Rating, 0)
-		glayers := layers[trimmed[i]:teamSizes[i]]
+		glayers := g.ratingLayer[trimmed[i]:g.teamSizes[i]]
 		for _, layer := range glayers {
 			r := NewRating(layer.Var().Mu(), layer.Var().Sigma(), 1)
 			group = append(group, r)
@@ -158,7 +292,7 @@ func (s *TrueSkill) Rate(ratingGroups [][]*Rating) ([][]*Rating, error) {
 		transformedGroups = append(transformedGroups, group)
 	}
 
-	return transformedGroups, nil
+	return transformedGroups
 }
 
 func (s *TrueSkill) validateRatingGroup(ratingGroups [][]*Rating) error {
@@ -195,101 +329,60 @@ func (s *TrueSkill) Rate1v1(
 }
 
 // runSchedule sends messages within every nodes of the factor graph until the result is reliable.
-func (s *TrueSkill) runSchedule(
-	ratingVars []*factorgraph.Variable,
-	flattenRatings []*Rating,
-	perfVars []*factorgraph.Variable,
-	teamPerfVars []*factorgraph.Variable,
-	teamSizes []int,
-	flattenWeights []float64,
-	teamDiffVars []*factorgraph.Variable,
-	sortedRanks []int,
-	sortedRatingGroups [][]*Rating,
-) ([]*factorgraph.PriorFactor, error) {
-	ratingLayer := s.buildRatingLayer(ratingVars, flattenRatings)
-	perfLayer := s.buildPerfLayer(ratingVars, perfVars)
-	teamPerfLayer := s.buildTeamPerfLayer(
-		teamPerfVars,
-		perfVars,
-		teamSizes,
-		flattenWeights,
-	)
-
-	for _, f := range ratingLayer {
-		f.Down()
-	}
-	for _, f := range perfLayer {
-		f.Down()
-	}
-	for _, f := range teamPerfLayer {
-		f.Down()
+func (s *TrueSkill) runSchedule(g *rateGraph, constraints []Constraint, cfg *rateConfig) error {
+	teamDiffVars := make([]*factorgraph.Variable, 0, len(constraints))
+	for i := 0; i < len(constraints); i++ {
+		teamDiffVars = append(teamDiffVars, factorgraph.NewVariable(mathmatics.NewGaussian(0, 0)))
 	}
 
 	// Arrow #1, #2, #3
-	teamDiffLayer := s.buildTeamDiffLayer(teamPerfVars, teamDiffVars)
-	truncLayer := s.buildTruncLayer(teamDiffVars, sortedRanks, sortedRatingGroups)
-	teamDiffLen := len(teamDiffLayer)
-
-	for index := 0; index <= 10; index++ {
-		delta := 0.0
-		var err error
-		if teamDiffLen == 1 {
-			// Only two teams
-			teamDiffLayer[0].Down()
-			delta, err = truncLayer[0].Up()
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			// Multiple teams
-			for z := 0; z < teamDiffLen-1; z++ {
-				teamDiffLayer[z].Down()
-				d, err := truncLayer[z].Up()
-				if err != nil {
-					return nil, err
-				}
-				delta = math.Max(delta, d)
-				teamDiffLayer[z].SetPointer(1)
-				teamDiffLayer[z].Up()
-			}
-
-			for z := teamDiffLen - 1; z > 0; z-- {
-				teamDiffLayer[z].Down()
-				d, err := truncLayer[z].Up()
-				if err != nil {
-					return nil, err
-				}
-				delta = math.Max(delta, d)
-				teamDiffLayer[z].SetPointer(0)
-				teamDiffLayer[z].Up()
-			}
-		}
-
-		// Repeat until too small update
-		if delta <= MinDelta {
-			break
+	teamDiffLayer := s.buildTeamDiffLayer(g.teamPerfVars, teamDiffVars, constraints)
+	truncLayer := s.buildTruncLayer(teamDiffVars, constraints, g.ratingGroups)
+
+	var graph *factorgraph.Graph
+	if cfg.inspect != nil {
+		graph = buildGraph(
+			g.ratingVars,
+			g.perfVars,
+			g.teamPerfVars,
+			teamDiffVars,
+			g.ratingLayer,
+			g.perfLayer,
+			g.teamPerfLayer,
+			teamDiffLayer,
+			truncLayer,
+		)
+	}
+
+	inspect := func(iteration int) {
+		if graph != nil {
+			cfg.inspect(iteration, graph)
 		}
 	}
 
-	// Up both ends
-	teamDiffLayer[0].SetPointer(0)
-	teamDiffLayer[0].Up()
-	teamDiffLayer[teamDiffLen-1].SetPointer(1)
-	teamDiffLayer[teamDiffLen-1].Up()
-
-	// Up the remainder of the black arrows
-	for _, f := range teamPerfLayer {
-		f.SetPointer(0)
-		for x := 0; x < len(f.Vars)-1; x++ {
-			f.Up()
-		}
+	iterations, converged, err := s.passMessages(g.perfLayer, g.teamPerfLayer, teamDiffLayer, truncLayer, constraints, inspect)
+	if err != nil {
+		return err
 	}
 
-	for _, f := range perfLayer {
-		f.Up()
+	inspect(iterations)
+
+	if cfg.trace != nil {
+		cfg.trace.Iterations = iterations
+		cfg.trace.Converged = converged
+		cfg.trace.record(
+			g.ratingLayer,
+			g.flattenRatings,
+			g.perfLayer,
+			g.teamSizes,
+			g.teamPerfVars,
+			teamDiffVars,
+			truncLayer,
+			constraints,
+		)
 	}
 
-	return ratingLayer, nil
+	return nil
 }
 
 func (s *TrueSkill) buildRatingLayer(ratingVars []*factorgraph.Variable, flattenRatings []*Rating) []*factorgraph.PriorFactor {
@@ -344,15 +437,16 @@ func (s *TrueSkill) buildTeamPerfLayer(
 	return layer
 }
 
-func (s *TrueSkill) buildTeamDiffLayer(teamPerfVars []*factorgraph.Variable, teamDiffVars []*factorgraph.Variable) []*factorgraph.SumFactor {
+func (s *TrueSkill) buildTeamDiffLayer(
+	teamPerfVars []*factorgraph.Variable,
+	teamDiffVars []*factorgraph.Variable,
+	constraints []Constraint,
+) []*factorgraph.SumFactor {
 	layer := make([]*factorgraph.SumFactor, 0, len(teamDiffVars))
 
-	team := 0
-
-	for _, v := range teamDiffVars {
-
-		sl := teamPerfVars[team : team+2]
-		team++
+	for i, v := range teamDiffVars {
+		c := constraints[i]
+		sl := []*factorgraph.Variable{teamPerfVars[c.Winner], teamPerfVars[c.Loser]}
 
 		f := factorgraph.NewSumFactor(v, sl, []float64{1, -1})
 		layer = append(layer, f)
@@ -363,31 +457,25 @@ func (s *TrueSkill) buildTeamDiffLayer(teamPerfVars []*factorgraph.Variable, tea
 
 func (s *TrueSkill) buildTruncLayer(
 	teamDiffVars []*factorgraph.Variable,
-	sortedRanks []int,
+	constraints []Constraint,
 	sortedRatingGroups [][]*Rating,
-) []factorgraph.Factor {
-
-	x := 0
+) []*factorgraph.TruncateFactor {
 
-	layer := make([]factorgraph.Factor, 0, len(teamDiffVars))
+	layer := make([]*factorgraph.TruncateFactor, 0, len(teamDiffVars))
 
-	for _, v := range teamDiffVars {
-		size := 0
-
-		for _, r := range sortedRatingGroups[x : x+2] {
-			size += len(r)
-		}
+	for i, v := range teamDiffVars {
+		c := constraints[i]
+		size := len(sortedRatingGroups[c.Winner]) + len(sortedRatingGroups[c.Loser])
 
 		drawMargin := s.calcDrawMargin(size)
 
 		vFunc := func(a float64, b float64) float64 { return s.vWin(a, b) }
 		wFunc := func(a float64, b float64) (float64, error) { return s.wWin(a, b) }
-		if sortedRanks[x] == sortedRanks[x+1] {
+		if c.Draw {
 			vFunc = func(a float64, b float64) float64 { return s.vDraw(a, b) }
 			wFunc = func(a float64, b float64) (float64, error) { return s.wDraw(a, b) }
 		}
 
-		x++
 		f := factorgraph.NewTruncateFactor(v, vFunc, wFunc, drawMargin)
 		layer = append(layer, f)
 	}
@@ -0,0 +1,227 @@
+package wenglin
+
+import (
+	"math"
+
+	"github.com/chobie/go-gaussian"
+)
+
+// Model is an update model of Weng and Lin.
+type Model int
+
+const (
+	// PlackettLuce models a ranking as teams chosen one by one from the
+	// best. It is the recommended model for free-for-alls.
+	PlackettLuce Model = iota
+	// BradleyTerryFull compares every pair of teams by the logistic model.
+	BradleyTerryFull
+	// BradleyTerryPart compares teams next to each other by rank by the
+	// logistic model.
+	BradleyTerryPart
+	// ThurstoneMostellerFull compares every pair of teams by the normal
+	// model like TrueSkill.
+	ThurstoneMostellerFull
+	// ThurstoneMostellerPart compares teams next to each other by rank by
+	// the normal model.
+	ThurstoneMostellerPart
+)
+
+func (m Model) updates(w *WengLin, teams []team) []update {
+	switch m {
+	case BradleyTerryFull:
+		return w.pairwise(teams, allPairs(teams), w.bradleyTerry)
+	case BradleyTerryPart:
+		return w.pairwise(teams, adjacentPairs(teams), w.bradleyTerry)
+	case ThurstoneMostellerFull:
+		return w.pairwise(teams, allPairs(teams), w.thurstoneMosteller)
+	case ThurstoneMostellerPart:
+		return w.pairwise(teams, adjacentPairs(teams), w.thurstoneMosteller)
+	default:
+		return w.plackettLuce(teams)
+	}
+}
+
+// plackettLuce is the algorithm 4 of Weng and Lin.
+func (w *WengLin) plackettLuce(teams []team) []update {
+	c := 0.0
+	for _, t := range teams {
+		c += t.sigmaSq + w.beta*w.beta
+	}
+	c = math.Sqrt(c)
+
+	// sums[q] is the sum over the teams not better than q, and ties[q] is
+	// the number of teams tied with q.
+	sums := make([]float64, len(teams))
+	ties := make([]float64, len(teams))
+	for q, tq := range teams {
+		for _, t := range teams {
+			if t.rank >= tq.rank {
+				sums[q] += math.Exp(t.mu / c)
+			}
+			if t.rank == tq.rank {
+				ties[q]++
+			}
+		}
+	}
+
+	updates := make([]update, len(teams))
+	for i, ti := range teams {
+		omega := 0.0
+		delta := 0.0
+		for q, tq := range teams {
+			if tq.rank > ti.rank {
+				continue
+			}
+			p := math.Exp(ti.mu/c) / sums[q]
+			if q == i {
+				omega += (1 - p) / ties[q]
+			} else {
+				omega -= p / ties[q]
+			}
+			delta += p * (1 - p) / ties[q]
+		}
+
+		gamma := math.Sqrt(ti.sigmaSq) / c
+		updates[i] = update{
+			omega: ti.sigmaSq / c * omega,
+			delta: gamma * ti.sigmaSq / (c * c) * delta,
+		}
+	}
+
+	return updates
+}
+
+// pairwise sums the updates of each team over the pairs by the comparison
+// model, which returns the updates of the first team of a pair.
+func (w *WengLin) pairwise(teams []team, pairs [][2]int, compare func(a team, b team) update) []update {
+	updates := make([]update, len(teams))
+	for _, p := range pairs {
+		for _, ab := range [][2]int{p, {p[1], p[0]}} {
+			u := compare(teams[ab[0]], teams[ab[1]])
+			updates[ab[0]].omega += u.omega
+			updates[ab[0]].delta += u.delta
+		}
+	}
+	return updates
+}
+
+func allPairs(teams []team) [][2]int {
+	pairs := make([][2]int, 0)
+	for i := range teams {
+		for j := i + 1; j < len(teams); j++ {
+			pairs = append(pairs, [2]int{i, j})
+		}
+	}
+	return pairs
+}
+
+func adjacentPairs(teams []team) [][2]int {
+	order := byRank(teams)
+	pairs := make([][2]int, 0, len(order)-1)
+	for i := 0; i < len(order)-1; i++ {
+		pairs = append(pairs, [2]int{order[i], order[i+1]})
+	}
+	return pairs
+}
+
+// score is 1 if a is ranked better than b, 0.5 for a draw and 0 otherwise.
+func score(a team, b team) float64 {
+	switch {
+	case a.rank < b.rank:
+		return 1
+	case a.rank == b.rank:
+		return 0.5
+	default:
+		return 0
+	}
+}
+
+// bradleyTerry is the algorithm 1 of Weng and Lin.
+func (w *WengLin) bradleyTerry(a team, b team) update {
+	c := math.Sqrt(a.sigmaSq + b.sigmaSq + 2*w.beta*w.beta)
+	p := 1 / (1 + math.Exp((b.mu-a.mu)/c))
+	gamma := math.Sqrt(a.sigmaSq) / c
+
+	return update{
+		omega: a.sigmaSq / c * (score(a, b) - p),
+		delta: gamma * a.sigmaSq / (c * c) * p * (1 - p),
+	}
+}
+
+// thurstoneMosteller is the algorithm 2 of Weng and Lin.
+func (w *WengLin) thurstoneMosteller(a team, b team) update {
+	c := math.Sqrt(a.sigmaSq + b.sigmaSq + 2*w.beta*w.beta)
+	x := (a.mu - b.mu) / c
+	t := w.margin / c
+	gamma := math.Sqrt(a.sigmaSq) / c
+
+	var dv, dw float64
+	switch {
+	case a.rank < b.rank:
+		dv, dw = vWin(x, t), wWin(x, t)
+	case a.rank > b.rank:
+		dv, dw = -vWin(-x, t), wWin(-x, t)
+	default:
+		dv, dw = vDraw(x, t), wDraw(x, t)
+	}
+
+	return update{
+		omega: a.sigmaSq / c * dv,
+		delta: gamma * a.sigmaSq / (c * c) * dw,
+	}
+}
+
+var normal = gaussian.NewGaussian(0.0, 1.0)
+
+// vWin is the mean correction of a win by x with the margin t.
+func vWin(x float64, t float64) float64 {
+	d := x - t
+	denom := normal.Cdf(d)
+	if denom < 1e-12 {
+		return -d
+	}
+	return normal.Pdf(d) / denom
+}
+
+// wWin is the variance correction of a win by x with the margin t.
+func wWin(x float64, t float64) float64 {
+	d := x - t
+	if normal.Cdf(d) < 1e-12 {
+		if x < 0 {
+			return 1
+		}
+		return 0
+	}
+	v := vWin(x, t)
+	return v * (v + d)
+}
+
+// vDraw is the mean correction of a draw with the difference x and the
+// margin t.
+func vDraw(x float64, t float64) float64 {
+	abs := math.Abs(x)
+	denom := normal.Cdf(t-abs) - normal.Cdf(-t-abs)
+	if denom < 1e-12 {
+		if x < 0 {
+			return -x - t
+		}
+		return -x + t
+	}
+	v := (normal.Pdf(-t-abs) - normal.Pdf(t-abs)) / denom
+	if x < 0 {
+		return -v
+	}
+	return v
+}
+
+// wDraw is the variance correction of a draw with the difference x and the
+// margin t.
+func wDraw(x float64, t float64) float64 {
+	abs := math.Abs(x)
+	denom := normal.Cdf(t-abs) - normal.Cdf(-t-abs)
+	if denom < 1e-12 {
+		return 1
+	}
+	v := vDraw(x, t)
+	return v*v + ((t-abs)*normal.Pdf(t-abs)+(t+abs)*normal.Pdf(-t-abs))/denom
+}
@@ -0,0 +1,254 @@
+// Package wenglin implements the Bayesian approximation of Weng and Lin,
+// which rates matches by closed-form updates instead of the factor graph of
+// TrueSkill. It is much faster for matches of many teams.
+package wenglin
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/chobie/go-gaussian"
+	"github.com/gami/go-trueskill"
+)
+
+const (
+	defaultMu         = 25.0
+	defaultSigmaDenom = 3
+	defaultBetaDenom  = 2
+	defaultTauDenom   = 100
+	defaultKappa      = 0.0001
+	defaultMargin     = 0.1
+)
+
+// WengLin represents environment of rating by the Weng-Lin models. The
+// parameters mean the same as those of trueskill.TrueSkill.
+type WengLin struct {
+	mu     float64 // the initial mean of ratings.
+	sigma  float64 // the initial standard deviation of ratings.
+	beta   float64 // the standard deviation of a performance.
+	tau    float64 // the dynamic factor added to sigma before each update.
+	kappa  float64 // the lower bound of the ratio by which a variance shrinks.
+	margin float64 // the draw margin of the Thurstone-Mosteller models.
+	model  Model
+}
+
+type option func(*WengLin)
+
+func New(options ...option) *WengLin {
+	w := &WengLin{
+		mu:     defaultMu,
+		kappa:  defaultKappa,
+		margin: defaultMargin,
+		model:  PlackettLuce,
+	}
+
+	for _, opt := range options {
+		opt(w)
+	}
+
+	if w.sigma == 0 {
+		w.sigma = w.mu / defaultSigmaDenom
+	}
+	if w.beta == 0 {
+		w.beta = w.sigma / defaultBetaDenom
+	}
+	if w.tau == 0 {
+		w.tau = w.sigma / defaultTauDenom
+	}
+
+	return w
+}
+
+func MU(v float64) option {
+	return func(w *WengLin) {
+		w.mu = v
+	}
+}
+
+func Sigma(v float64) option {
+	return func(w *WengLin) {
+		w.sigma = v
+	}
+}
+
+func Beta(v float64) option {
+	return func(w *WengLin) {
+		w.beta = v
+	}
+}
+
+func Tau(v float64) option {
+	return func(w *WengLin) {
+		w.tau = v
+	}
+}
+
+// Kappa is the lower bound of the ratio by which a variance shrinks by a
+// match, which keeps it positive. The default is 0.0001.
+func Kappa(v float64) option {
+	return func(w *WengLin) {
+		w.kappa = v
+	}
+}
+
+// Margin is the draw margin of the Thurstone-Mosteller models. The default
+// is 0.1.
+func Margin(v float64) option {
+	return func(w *WengLin) {
+		w.margin = v
+	}
+}
+
+// UseModel sets the update model. The default is PlackettLuce.
+func UseModel(m Model) option {
+	return func(w *WengLin) {
+		w.model = m
+	}
+}
+
+var _ trueskill.Rater = (*WengLin)(nil)
+
+func (w *WengLin) CreateRating() *trueskill.Rating {
+	return trueskill.NewRating(w.mu, w.sigma, 1)
+}
+
+// team is the sum of the ratings of a team.
+type team struct {
+	mu      float64
+	sigmaSq float64
+	rank    int
+}
+
+// update is the change of a team: omega moves the mean and delta shrinks
+// the variance.
+type update struct {
+	omega float64
+	delta float64
+}
+
+// Rate recalculates ratings by the ranks of the groups. Lower rank is better
+// and equal ranks are a draw. Groups are sorted by rank if ranks is nil.
+func (w *WengLin) Rate(ratingGroups [][]*trueskill.Rating, ranks []int) ([][]*trueskill.Rating, error) {
+	if len(ratingGroups) < 2 {
+		return nil, errors.New("need multiple rating groups")
+	}
+	for _, g := range ratingGroups {
+		if len(g) < 1 {
+			return nil, errors.New("each group must contain multiple ratings")
+		}
+	}
+	if ranks != nil && len(ranks) != len(ratingGroups) {
+		return nil, fmt.Errorf("got %d ranks for %d groups", len(ranks), len(ratingGroups))
+	}
+
+	tauSq := w.tau * w.tau
+	teams := make([]team, 0, len(ratingGroups))
+	for i, g := range ratingGroups {
+		t := team{rank: i}
+		if ranks != nil {
+			t.rank = ranks[i]
+		}
+		for _, r := range g {
+			t.mu += r.Mu
+			t.sigmaSq += r.Sigma*r.Sigma + tauSq
+		}
+		teams = append(teams, t)
+	}
+
+	updates := w.model.updates(w, teams)
+
+	rated := make([][]*trueskill.Rating, 0, len(ratingGroups))
+	for i, g := range ratingGroups {
+		t, u := teams[i], updates[i]
+		ratedGroup := make([]*trueskill.Rating, 0, len(g))
+		for _, r := range g {
+			sigmaSq := r.Sigma*r.Sigma + tauSq
+			ratio := sigmaSq / t.sigmaSq
+			mu := r.Mu + ratio*u.omega
+			sigmaSq *= math.Max(1-ratio*u.delta, w.kappa)
+			ratedGroup = append(ratedGroup, trueskill.NewRating(mu, math.Sqrt(sigmaSq), r.Weight))
+		}
+		rated = append(rated, ratedGroup)
+	}
+
+	return rated, nil
+}
+
+// RateMatch recalculates ratings of the players in the match. Players missing
+// in ratings start from CreateRating. It returns new ratings by player ID.
+func (w *WengLin) RateMatch(m *trueskill.Match, ratings map[string]*trueskill.Rating) (map[string]*trueskill.Rating, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	rated, err := w.Rate(trueskill.Groups(w, m, ratings), m.Ranks)
+	if err != nil {
+		return nil, err
+	}
+
+	return trueskill.ByPlayer(m, rated), nil
+}
+
+// pair returns the difference of the means of team a over team b and the
+// standard deviation of the difference of their performances.
+func (w *WengLin) pair(a []*trueskill.Rating, b []*trueskill.Rating) (float64, float64) {
+	mu := 0.0
+	sigmaSq := float64(len(a)+len(b)) * w.beta * w.beta
+	for _, r := range a {
+		mu += r.Mu
+		sigmaSq += r.Sigma * r.Sigma
+	}
+	for _, r := range b {
+		mu -= r.Mu
+		sigmaSq += r.Sigma * r.Sigma
+	}
+	return mu, math.Sqrt(sigmaSq)
+}
+
+// WinProbability returns the probability that the performance of team a is
+// higher than team b.
+func (w *WengLin) WinProbability(a []*trueskill.Rating, b []*trueskill.Rating) float64 {
+	mu, sigma := w.pair(a, b)
+	return gaussian.NewGaussian(0.0, 1.0).Cdf(mu / sigma)
+}
+
+// Quality returns the mean over pairs of teams of the quality of TrueSkill
+// for two teams.
+func (w *WengLin) Quality(ratingGroups [][]*trueskill.Rating) (float64, error) {
+	if len(ratingGroups) < 2 {
+		return 0, errors.New("need multiple rating groups")
+	}
+
+	sum := 0.0
+	pairs := 0
+	for i := range ratingGroups {
+		for j := i + 1; j < len(ratingGroups); j++ {
+			mu, sigma := w.pair(ratingGroups[i], ratingGroups[j])
+			n := float64(len(ratingGroups[i])+len(ratingGroups[j])) * w.beta * w.beta
+			sum += math.Sqrt(n/(sigma*sigma)) * math.Exp(-mu*mu/(2*sigma*sigma))
+			pairs++
+		}
+	}
+
+	return sum / float64(pairs), nil
+}
+
+// Expose returns the value of the rating exposure like TrueSkill.
+func (w *WengLin) Expose(r *trueskill.Rating) float64 {
+	k := w.mu / w.sigma
+	return r.Mu - k*r.Sigma
+}
+
+// byRank returns the indexes of the teams sorted by rank.
+func byRank(teams []team) []int {
+	order := make([]int, len(teams))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return teams[order[a]].rank < teams[order[b]].rank
+	})
+	return order
+}
@@ -0,0 +1,96 @@
+package wenglin_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/gami/go-trueskill"
+	"github.com/gami/go-trueskill/wenglin"
+)
+
+var models = []wenglin.Model{
+	wenglin.PlackettLuce,
+	wenglin.BradleyTerryFull,
+	wenglin.BradleyTerryPart,
+	wenglin.ThurstoneMostellerFull,
+	wenglin.ThurstoneMostellerPart,
+}
+
+// TestPlackettLuce1v1 compares with the reference implementation openskill.
+func TestPlackettLuce1v1(t *testing.T) {
+	w := wenglin.New(wenglin.Tau(1e-12))
+	rated, err := w.Rate([][]*trueskill.Rating{{w.CreateRating()}, {w.CreateRating()}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		got  *trueskill.Rating
+		want *trueskill.Rating
+	}{
+		{rated[0][0], trueskill.NewRating(27.63523138347365, 8.065506316323548, 1)},
+		{rated[1][0], trueskill.NewRating(22.36476861652635, 8.065506316323548, 1)},
+	} {
+		if math.Abs(c.got.Mu-c.want.Mu) > 1e-9 || math.Abs(c.got.Sigma-c.want.Sigma) > 1e-9 {
+			t.Errorf("got %+v, want %+v", c.got, c.want)
+		}
+	}
+}
+
+func TestModels(t *testing.T) {
+	for _, m := range models {
+		w := wenglin.New(wenglin.UseModel(m))
+
+		// Ranks of teams of two, where the last two teams are tied.
+		groups := make([][]*trueskill.Rating, 0)
+		for i := 0; i < 4; i++ {
+			groups = append(groups, []*trueskill.Rating{w.CreateRating(), w.CreateRating()})
+		}
+		rated, err := w.Rate(groups, []int{0, 1, 2, 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		prior := w.CreateRating()
+		if rated[0][0].Mu <= prior.Mu || rated[3][0].Mu > prior.Mu {
+			t.Errorf("model %d: got %+v and %+v", m, rated[0][0], rated[3][0])
+		}
+		if rated[0][0].Mu <= rated[1][0].Mu || rated[1][0].Mu <= rated[2][0].Mu {
+			t.Errorf("model %d: means must follow the ranks", m)
+		}
+		if math.Abs(rated[2][0].Mu-rated[3][0].Mu) > 1e-9 && m != wenglin.BradleyTerryPart && m != wenglin.ThurstoneMostellerPart {
+			t.Errorf("model %d: tied teams must get the same mean", m)
+		}
+		for _, g := range rated {
+			for _, r := range g {
+				if r.Sigma >= prior.Sigma {
+					t.Errorf("model %d: sigma must shrink, got %v", m, r.Sigma)
+				}
+			}
+		}
+	}
+}
+
+func TestRateMatchFreeForAll(t *testing.T) {
+	w := wenglin.New()
+	m := &trueskill.Match{ID: "ffa"}
+	for i := 0; i < 100; i++ {
+		m.Teams = append(m.Teams, []string{fmt.Sprintf("p%d", i)})
+	}
+
+	rated, err := w.RateMatch(m, map[string]*trueskill.Rating{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < 100; i++ {
+		if rated[fmt.Sprintf("p%d", i-1)].Mu <= rated[fmt.Sprintf("p%d", i)].Mu {
+			t.Fatalf("p%d must be above p%d", i-1, i)
+		}
+	}
+
+	var r trueskill.Rater = w
+	if p := r.WinProbability([]*trueskill.Rating{rated["p0"]}, []*trueskill.Rating{rated["p99"]}); p <= 0.5 {
+		t.Errorf("got %v", p)
+	}
+}